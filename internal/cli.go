@@ -0,0 +1,334 @@
+package internal
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const cliUsage = `Usage: terraform-provider-ct render --content FILE [options]
+
+Render a Butane config to Ignition, exactly as the ct_config data source
+would, without Terraform. The Ignition config is written to stdout and
+diagnostics to stderr. Inputs are named as in ct_config, so snippets[0] is the
+first --snippet, ignition_snippets[0] the first --ignition-snippet, and
+trees[0] the first --tree. With --explain, the explanation report is written to
+stderr after any diagnostics.
+
+Named snippets are merged sorted by name. Snippet blocks with their own
+files_dir and tree include, exclude, modes, and max_file_size have no flags.
+
+Options:
+`
+
+// RunCLI runs a subcommand of the provider binary (e.g. render) and returns
+// its exit code, so rendering can be debugged without Terraform.
+func RunCLI(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return 2
+	}
+	switch args[0] {
+	case "render":
+	case "help", "-h", "-help", "--help":
+		printUsage(stdout)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown subcommand %q\n\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+
+	var opts renderOptions
+	flags := renderFlags(stderr, &opts)
+	// usage is printed below, to stdout if requested with -h
+	flags.Usage = func() {}
+	if err := flags.Parse(args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			printUsage(stdout)
+			return 0
+		}
+		printUsage(stderr)
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments: %s\n", strings.Join(flags.Args(), " "))
+		return 2
+	}
+	if opts.content == "" {
+		fmt.Fprintln(stderr, "--content is required")
+		return 2
+	}
+	if _, ok := ignitionSpecs[opts.ignitionVersion]; !ok {
+		fmt.Fprintf(stderr, "--ignition-version must be one of %v\n", ignitionVersions())
+		return 2
+	}
+	if !slices.Contains(conflictPolicies, opts.conflictPolicy) {
+		fmt.Fprintf(stderr, "--conflict-policy must be one of %v\n", conflictPolicies)
+		return 2
+	}
+	if _, ok := platformLimits[opts.platform]; opts.platform != "" && !ok {
+		fmt.Fprintf(stderr, "--platform must be one of %v\n", platforms())
+		return 2
+	}
+	if opts.stdinInputs() > 1 {
+		fmt.Fprintln(stderr, "stdin (-) may only be read by one input")
+		return 2
+	}
+
+	cfg, err := opts.configModel(stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	diags := cfg.render(context.Background(), nil)
+	printDiagnostics(stderr, diags)
+	if diags.HasError() {
+		return 1
+	}
+	if opts.explain {
+		fmt.Fprint(stderr, cfg.ExplanationReport.ValueString())
+	}
+	fmt.Fprintln(stdout, cfg.Rendered.ValueString())
+	return 0
+}
+
+// printUsage writes the usage of the render subcommand and its flags.
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, cliUsage)
+	renderFlags(w, &renderOptions{}).PrintDefaults()
+}
+
+// renderOptions are the flags of the render subcommand, which mirror
+// ct_config arguments.
+type renderOptions struct {
+	content          string
+	snippets         fileList
+	namedSnippets    namedFileList
+	ignitionSnippets fileList
+	trees            treeList
+	filesDir         string
+	strict           bool
+	lint             bool
+	explain          bool
+	conflictPolicy   string
+	pretty           bool
+	ignitionVersion  string
+	platform         string
+	maxSize          int64
+}
+
+func renderFlags(output io.Writer, opts *renderOptions) *flag.FlagSet {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.StringVar(&opts.content, "content", "", "Butane config file (- for stdin)")
+	flags.Var(&opts.snippets, "snippet", "Butane snippet file to merge (repeatable)")
+	flags.Var(&opts.namedSnippets, "named-snippet", "`NAME=FILE` of a Butane snippet to merge after the snippets (repeatable)")
+	flags.Var(&opts.ignitionSnippets, "ignition-snippet", "Ignition JSON file to merge after the Butane snippets (repeatable)")
+	flags.Var(&opts.trees, "tree", "`LOCAL:PATH` of a local directory whose files are added under PATH (repeatable)")
+	flags.StringVar(&opts.filesDir, "files-dir", "", "allow embedding local files relative to this directory")
+	flags.BoolVar(&opts.strict, "strict", false, "treat validation warnings as errors")
+	flags.BoolVar(&opts.lint, "lint", false, "check the merged config for common mistakes")
+	flags.BoolVar(&opts.explain, "explain", false, "write which input last set each entry to stderr")
+	flags.StringVar(&opts.conflictPolicy, "conflict-policy", conflictAllow, "allow, warn, or error when inputs set the same entry differently")
+	flags.BoolVar(&opts.pretty, "pretty-print", false, "indent the Ignition config")
+	flags.StringVar(&opts.ignitionVersion, "ignition-version", defaultIgnitionVersion, "Ignition spec version to render")
+	flags.StringVar(&opts.platform, "platform", "", "platform whose user-data size limit the config must fit")
+	flags.Int64Var(&opts.maxSize, "max-size", 0, "maximum size of the config in bytes, overriding the platform limit")
+	return flags
+}
+
+// fileList is a repeatable flag of file names.
+type fileList []string
+
+func (l *fileList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *fileList) Set(name string) error {
+	*l = append(*l, name)
+	return nil
+}
+
+// namedFile is a file of a named snippet.
+type namedFile struct {
+	name string
+	file string
+}
+
+// namedFileList is a repeatable flag of NAME=FILE pairs.
+type namedFileList []namedFile
+
+func (l *namedFileList) String() string {
+	var pairs []string
+	for _, f := range *l {
+		pairs = append(pairs, f.name+"="+f.file)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (l *namedFileList) Set(value string) error {
+	name, file, ok := strings.Cut(value, "=")
+	if !ok || name == "" || file == "" {
+		return fmt.Errorf("must be NAME=FILE")
+	}
+	for _, f := range *l {
+		if f.name == name {
+			return fmt.Errorf("duplicate snippet name %q", name)
+		}
+	}
+	*l = append(*l, namedFile{name: name, file: file})
+	return nil
+}
+
+// treeList is a repeatable flag of LOCAL:PATH trees.
+type treeList []treeModel
+
+func (l *treeList) String() string {
+	var pairs []string
+	for _, t := range *l {
+		pairs = append(pairs, t.Local.ValueString()+":"+t.Path.ValueString())
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (l *treeList) Set(value string) error {
+	// split at the last colon, since destination paths are absolute
+	i := strings.LastIndex(value, ":")
+	if i <= 0 || i == len(value)-1 {
+		return fmt.Errorf("must be LOCAL:PATH")
+	}
+	*l = append(*l, treeModel{
+		Local:       types.StringValue(value[:i]),
+		Path:        types.StringValue(value[i+1:]),
+		Include:     types.ListNull(types.StringType),
+		Exclude:     types.ListNull(types.StringType),
+		Modes:       types.MapNull(types.StringType),
+		MaxFileSize: types.Int64Null(),
+	})
+	return nil
+}
+
+// stdinInputs returns the number of inputs read from stdin, which can only be
+// read once.
+func (opts renderOptions) stdinInputs() int {
+	names := append([]string{opts.content}, opts.snippets...)
+	names = append(names, opts.ignitionSnippets...)
+	for _, f := range opts.namedSnippets {
+		names = append(names, f.file)
+	}
+	n := 0
+	for _, name := range names {
+		if name == "-" {
+			n++
+		}
+	}
+	return n
+}
+
+// configModel reads the input files into the attributes of a ct_config.
+func (opts renderOptions) configModel(stdin io.Reader) (*configModel, error) {
+	content, err := readInput(opts.content, stdin)
+	if err != nil {
+		return nil, err
+	}
+	snippets, err := readInputs(opts.snippets, stdin)
+	if err != nil {
+		return nil, err
+	}
+	ignitionSnippets, err := readInputs(opts.ignitionSnippets, stdin)
+	if err != nil {
+		return nil, err
+	}
+	namedSnippets := types.MapNull(types.StringType)
+	if len(opts.namedSnippets) > 0 {
+		elems := map[string]attr.Value{}
+		for _, f := range opts.namedSnippets {
+			content, err := readInput(f.file, stdin)
+			if err != nil {
+				return nil, err
+			}
+			elems[f.name] = types.StringValue(content)
+		}
+		namedSnippets = types.MapValueMust(types.StringType, elems)
+	}
+
+	// unset attributes are null, as when omitted from a ct_config
+	cfg := &configModel{
+		Content:          types.StringValue(content),
+		Snippets:         snippets,
+		NamedSnippets:    namedSnippets,
+		IgnitionSnippets: ignitionSnippets,
+		Trees:            opts.trees,
+		PrettyPrint:      types.BoolValue(opts.pretty),
+		Strict:           types.BoolValue(opts.strict),
+		Lint:             types.BoolValue(opts.lint),
+		Explain:          types.BoolValue(opts.explain),
+		ConflictPolicy:   types.StringValue(opts.conflictPolicy),
+		IgnitionVersion:  types.StringValue(opts.ignitionVersion),
+	}
+	if opts.filesDir != "" {
+		cfg.FilesDir = types.StringValue(opts.filesDir)
+	}
+	if opts.platform != "" {
+		cfg.Platform = types.StringValue(opts.platform)
+	}
+	if opts.maxSize > 0 {
+		cfg.MaxSize = types.Int64Value(opts.maxSize)
+	}
+	return cfg, nil
+}
+
+// readInput reads a file, or stdin if the name is -.
+func readInput(name string, stdin io.Reader) (string, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read %s: %v", name, err)
+	}
+	return string(data), nil
+}
+
+// readInputs reads files into a list attribute, which is null if there are
+// none.
+func readInputs(names []string, stdin io.Reader) (types.List, error) {
+	if len(names) == 0 {
+		return types.ListNull(types.StringType), nil
+	}
+	var elems []attr.Value
+	for _, name := range names {
+		content, err := readInput(name, stdin)
+		if err != nil {
+			return types.ListNull(types.StringType), err
+		}
+		elems = append(elems, types.StringValue(content))
+	}
+	return types.ListValueMust(types.StringType, elems), nil
+}
+
+// printDiagnostics writes diagnostics like Terraform's CLI would.
+func printDiagnostics(w io.Writer, diags diag.Diagnostics) {
+	for _, d := range diags {
+		severity := "Warning"
+		if d.Severity() == diag.SeverityError {
+			severity = "Error"
+		}
+		fmt.Fprintf(w, "%s: %s\n", severity, d.Summary())
+		if d.Detail() != "" {
+			fmt.Fprintf(w, "  %s\n", d.Detail())
+		}
+	}
+}
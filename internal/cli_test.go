@@ -0,0 +1,163 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const cliContent = `
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - key
+`
+
+const cliSnippet = `
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      enabled: true
+`
+
+const cliSnippetUnknownKey = `
+variant: fcos
+version: 1.5.0
+unknown_key: true
+`
+
+func TestCLIRender(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"content.yaml": cliContent,
+		"snippet.yaml": cliSnippet,
+		"unknown.yaml": cliSnippetUnknownKey,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	tree := filepath.Join(dir, "tree")
+	if err := os.Mkdir(tree, 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(tree, "app.conf"), []byte("key=value\n"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	content := filepath.Join(dir, "content.yaml")
+
+	cases := []struct {
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{
+			args:   []string{"render", "--content", content, "--snippet", filepath.Join(dir, "snippet.yaml")},
+			stdout: ignitionV34WithSnippetsPrettyFalseExpected + "\n",
+		},
+		{
+			args:   []string{"render", "--content", "-", "--snippet", filepath.Join(dir, "snippet.yaml"), "--pretty-print"},
+			stdin:  cliContent,
+			stdout: ignitionV34WithSnippetsExpected + "\n",
+		},
+		{
+			args:   []string{"render", "--content", content, "--snippet", filepath.Join(dir, "unknown.yaml")},
+			stdout: `"version":"3.4.0"`,
+			stderr: "Warning: unused key unknown_key\n  snippets[0]: warning at $.unknown_key, line 4 col 1\n",
+		},
+		{
+			args:   []string{"render", "--content", content, "--snippet", filepath.Join(dir, "unknown.yaml"), "--strict"},
+			code:   1,
+			stderr: "Error: strict parsing error: unused key unknown_key",
+		},
+		{
+			args:   []string{"render", "--content", content, "--named-snippet", "docker=" + filepath.Join(dir, "snippet.yaml")},
+			stdout: `"systemd":{"units":[{"enabled":true,"name":"docker.service"}]}`,
+		},
+		{
+			args:   []string{"render", "--content", content, "--named-snippet", "unknown=" + filepath.Join(dir, "unknown.yaml")},
+			stdout: `"version":"3.4.0"`,
+			stderr: "Warning: unused key unknown_key\n  named_snippets[\"unknown\"]: warning at $.unknown_key, line 4 col 1\n",
+		},
+		{
+			args:   []string{"render", "--content", content, "--tree", tree + ":/etc/app"},
+			stdout: `"path":"/etc/app/app.conf","user":{},"contents":{"compression":"","source":"data:,key%3Dvalue%0A","verification":{}},"mode":420`,
+		},
+		{
+			args:   []string{"render", "--content", content, "--snippet", filepath.Join(dir, "snippet.yaml"), "--explain"},
+			stdout: `"version":"3.4.0"`,
+			stderr: "unit:docker.service: snippets[0]\nuser:core: content\n",
+		},
+		{
+			args:   []string{"render", "--content", content, "--named-snippet", filepath.Join(dir, "snippet.yaml")},
+			code:   2,
+			stderr: "invalid value",
+		},
+		{
+			args:   []string{"render", "--content", content, "--tree", tree},
+			code:   2,
+			stderr: "must be LOCAL:PATH",
+		},
+		{
+			args:   []string{"render", "--content", "-", "--snippet", "-"},
+			stdin:  cliContent,
+			code:   2,
+			stderr: "stdin (-) may only be read by one input",
+		},
+		{
+			args:   []string{"render", "--content", content, "--ignition-version", "2.3.0"},
+			code:   2,
+			stderr: "--ignition-version must be one of",
+		},
+		{
+			args:   []string{"render", "--content", filepath.Join(dir, "missing.yaml")},
+			code:   1,
+			stderr: "missing.yaml: no such file or directory",
+		},
+		{
+			args:   []string{"version"},
+			code:   2,
+			stderr: "unknown subcommand \"version\"\n\nUsage: terraform-provider-ct render",
+		},
+		{
+			args:   []string{"help"},
+			stdout: "Usage: terraform-provider-ct render",
+		},
+		{
+			args:   []string{"--help"},
+			stdout: "Usage: terraform-provider-ct render",
+		},
+		{
+			args:   []string{"render", "-h"},
+			stdout: "Usage: terraform-provider-ct render",
+		},
+		{
+			args:   []string{"render", "--unknown"},
+			code:   2,
+			stderr: "flag provided but not defined: -unknown",
+		},
+	}
+	for _, c := range cases {
+		var stdout, stderr bytes.Buffer
+		code := RunCLI(c.args, strings.NewReader(c.stdin), &stdout, &stderr)
+		if code != c.code {
+			t.Errorf("%v: expected exit code %d, got %d (stderr: %s)", c.args, c.code, code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), c.stdout) {
+			t.Errorf("%v: expected stdout to contain %q, got %q", c.args, c.stdout, stdout.String())
+		}
+		if !strings.Contains(stderr.String(), c.stderr) {
+			t.Errorf("%v: expected stderr to contain %q, got %q", c.args, c.stderr, stderr.String())
+		}
+	}
+}
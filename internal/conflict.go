@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// Policies for inputs that set the same keyed entry differently.
+const (
+	conflictAllow = "allow"
+	conflictWarn  = "warn"
+	conflictError = "error"
+)
+
+var conflictPolicies = []string{conflictAllow, conflictWarn, conflictError}
+
+// conflict is a keyed entry (e.g. file:/etc/hostname) that a later input
+// sets differently than an earlier input.
+type conflict struct {
+	id   string
+	prev keyedEntry
+	next keyedEntry
+	// fields set differently (e.g. contents.source)
+	fields []string
+}
+
+// findConflicts returns the keyed entries of inputs that override fields an
+// earlier input set to a different value. Ignition merges entries with the
+// same key field by field and appends lists, so only fields that are
+// replaced are compared.
+func findConflicts(sources []sourceConfig) ([]conflict, error) {
+	values, err := sourceValues(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	type setBy struct {
+		entry keyedEntry
+		value string
+	}
+	// fields of each keyed entry, with the entry that last set them
+	merged := map[string]map[string]setBy{}
+	var conflicts []conflict
+	for _, entry := range keyedEntries(values) {
+		fields, ok := merged[entry.id]
+		if !ok {
+			fields = map[string]setBy{}
+			merged[entry.id] = fields
+		}
+
+		byPrev := map[string]*conflict{}
+		var order []string
+		for field, value := range leafFields(entry.value, "") {
+			data, _ := json.Marshal(value)
+			prev, ok := fields[field]
+			if ok && prev.entry.in.name != entry.in.name && prev.value != string(data) {
+				c, ok := byPrev[prev.entry.in.name]
+				if !ok {
+					c = &conflict{id: entry.id, prev: prev.entry, next: entry}
+					byPrev[prev.entry.in.name] = c
+					order = append(order, prev.entry.in.name)
+				}
+				c.fields = append(c.fields, field)
+			}
+			fields[field] = setBy{entry: entry, value: string(data)}
+		}
+		sort.Strings(order)
+		for _, name := range order {
+			sort.Strings(byPrev[name].fields)
+			conflicts = append(conflicts, *byPrev[name])
+		}
+	}
+	return conflicts, nil
+}
+
+// leafFields returns the non-list leaf values of a JSON object by their
+// dotted field path (e.g. contents.source).
+func leafFields(obj map[string]interface{}, prefix string) map[string]interface{} {
+	leaves := map[string]interface{}{}
+	for key, value := range obj {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			for field, leaf := range leafFields(value, prefix+key+".") {
+				leaves[field] = leaf
+			}
+		case []interface{}:
+			// merged by Ignition, rather than replaced
+		default:
+			leaves[prefix+key] = value
+		}
+	}
+	return leaves
+}
+
+func (c conflict) message() string {
+	return fmt.Sprintf("%s from %s is overridden by %s with different %s", c.id, c.prev.in.name, c.next.in.name, strings.Join(c.fields, ", "))
+}
+
+func (c conflict) detail() string {
+	return fmt.Sprintf("%s at %s, %s at %s", c.prev.in.name, c.prev.path, c.next.in.name, c.next.path)
+}
+
+// conflictDiagnostics reports conflicts as warnings or errors, by policy.
+func conflictDiagnostics(conflicts []conflict, policy string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, c := range conflicts {
+		switch policy {
+		case conflictWarn:
+			diags.AddAttributeWarning(c.next.in.path, c.message(), c.detail())
+		case conflictError:
+			diags.AddAttributeError(c.next.in.path, fmt.Sprintf("conflict: %s", c.message()), c.detail())
+		}
+	}
+	return diags
+}
+
+// conflictWarnings returns conflicts as warnings.
+func conflictWarnings(conflicts []conflict) []warning {
+	var warnings []warning
+	for _, c := range conflicts {
+		warnings = append(warnings, warning{message: c.message(), path: c.next.path, source: c.next.in.name})
+	}
+	return warnings
+}
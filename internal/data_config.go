@@ -1,149 +1,732 @@
 package internal
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"sort"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 
 	butane "github.com/coreos/butane/config"
 	"github.com/coreos/butane/config/common"
-	ignition "github.com/coreos/ignition/v2/config/v3_4"
 )
 
-func DatasourceConfig() *schema.Resource {
-	return &schema.Resource{
-		ReadContext: datasourceConfigRead,
+// configDataSource renders Butane configs as Ignition. Its schema and state
+// match the SDKv2 ct_config data source it replaced.
+type configDataSource struct {
+	meta *providerMeta
+}
+
+var _ datasource.DataSourceWithConfigure = &configDataSource{}
+
+func NewConfigDataSource() datasource.DataSource {
+	return &configDataSource{}
+}
+
+// configDataSourceModel is the ct_config data source configuration and state.
+type configDataSourceModel struct {
+	ID                types.String `tfsdk:"id"`
+	Sensitive         types.Bool   `tfsdk:"sensitive"`
+	RenderedSensitive types.String `tfsdk:"rendered_sensitive"`
+	configModel
+}
+
+// configModel holds the attributes shared by the ct_config data source and
+// ephemeral resource.
+type configModel struct {
+	Content            types.String   `tfsdk:"content"`
+	Snippets           types.List     `tfsdk:"snippets"`
+	NamedSnippets      types.Map      `tfsdk:"named_snippets"`
+	NamedSnippetsOrder types.List     `tfsdk:"named_snippets_order"`
+	IgnitionSnippets   types.List     `tfsdk:"ignition_snippets"`
+	FilesDir           types.String   `tfsdk:"files_dir"`
+	PrettyPrint        types.Bool     `tfsdk:"pretty_print"`
+	Strict             types.Bool     `tfsdk:"strict"`
+	Lint               types.Bool     `tfsdk:"lint"`
+	Explain            types.Bool     `tfsdk:"explain"`
+	ConflictPolicy     types.String   `tfsdk:"conflict_policy"`
+	IgnitionVersion    types.String   `tfsdk:"ignition_version"`
+	Platform           types.String   `tfsdk:"platform"`
+	MaxSize            types.Int64    `tfsdk:"max_size"`
+	MaxSizeGzip        types.Bool     `tfsdk:"max_size_gzip"`
+	PointerURL         types.String   `tfsdk:"pointer_url"`
+	Rendered           types.String   `tfsdk:"rendered"`
+	RenderedBase64     types.String   `tfsdk:"rendered_base64"`
+	RenderedGzipBase64 types.String   `tfsdk:"rendered_gzip_base64"`
+	SHA256             types.String   `tfsdk:"sha256"`
+	SHA512             types.String   `tfsdk:"sha512"`
+	PointerRendered    types.String   `tfsdk:"pointer_rendered"`
+	FullRendered       types.String   `tfsdk:"full_rendered"`
+	Warnings           types.List     `tfsdk:"warnings"`
+	Files              types.List     `tfsdk:"files"`
+	SystemdUnits       types.List     `tfsdk:"systemd_units"`
+	Users              types.List     `tfsdk:"users"`
+	Filesystems        types.List     `tfsdk:"filesystems"`
+	Disks              types.List     `tfsdk:"disks"`
+	Luks               types.List     `tfsdk:"luks"`
+	SnippetBlocks      []snippetModel `tfsdk:"snippet"`
+	Trees              []treeModel    `tfsdk:"trees"`
+	Explanation        types.Map      `tfsdk:"explanation"`
+	ExplanationReport  types.String   `tfsdk:"explanation_report"`
+}
+
+func (d *configDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_config"
+}
 
-		Schema: map[string]*schema.Schema{
-			"content": {
-				Type:     schema.TypeString,
-				Required: true,
+func (d *configDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	attrs := configAttributes()
+	attrs["id"] = schema.StringAttribute{
+		Optional: true,
+		Computed: true,
+	}
+	attrs["sensitive"] = schema.BoolAttribute{
+		Optional:    true,
+		Description: "expose the rendered configuration only as rendered_sensitive, omitting plaintext outputs",
+	}
+	attrs["rendered_sensitive"] = schema.StringAttribute{
+		Computed:    true,
+		Sensitive:   true,
+		Description: "rendered ignition configuration, set instead of rendered when sensitive",
+	}
+	resp.Schema = schema.Schema{
+		Attributes: attrs,
+		Blocks:     configBlocks(),
+	}
+}
+
+// configAttributes returns the attributes shared by the ct_config data source
+// and ephemeral resource.
+func configAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"content": schema.StringAttribute{
+			Required: true,
+		},
+		"snippets": schema.ListAttribute{
+			ElementType: types.StringType,
+			Optional:    true,
+			Description: "Butane snippets to merge",
+		},
+		"named_snippets": schema.MapAttribute{
+			ElementType: types.StringType,
+			Optional:    true,
+			Description: "Butane snippets by name, merged after snippets in named_snippets_order or sorted by name",
+		},
+		"named_snippets_order": schema.ListAttribute{
+			ElementType: types.StringType,
+			Optional:    true,
+			Description: "order in which to merge named_snippets, listing every name",
+		},
+		"ignition_snippets": schema.ListAttribute{
+			ElementType: types.StringType,
+			Optional:    true,
+			Description: "Ignition JSON configs to merge after the Butane snippets",
+		},
+		"files_dir": schema.StringAttribute{
+			Optional:    true,
+			Description: "allow embedding local files relative to this directory",
+		},
+		"pretty_print": schema.BoolAttribute{
+			Optional: true,
+			Computed: true,
+		},
+		"strict": schema.BoolAttribute{
+			Optional: true,
+			Computed: true,
+		},
+		"lint": schema.BoolAttribute{
+			Optional:    true,
+			Description: "check the merged config for common mistakes, reported as warnings (errors if strict)",
+		},
+		"explain": schema.BoolAttribute{
+			Optional:    true,
+			Description: "record which input last set each file, unit, user, and storage entry in explanation",
+		},
+		"conflict_policy": schema.StringAttribute{
+			Optional:    true,
+			Description: "whether inputs that set the same file, unit, user, or storage entry differently are allowed (default), warned about, or an error",
+			Validators: []validator.String{
+				stringvalidator.OneOf(conflictPolicies...),
 			},
-			"snippets": {
-				Type: schema.TypeList,
-				Elem: &schema.Schema{
-					Type: schema.TypeString,
-				},
-				Optional: true,
-				ForceNew: true,
+		},
+		"ignition_version": schema.StringAttribute{
+			Optional:    true,
+			Computed:    true,
+			Description: "Ignition spec version of the rendered configuration",
+			Validators: []validator.String{
+				stringvalidator.OneOf(ignitionVersions()...),
 			},
-			"files_dir": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Default:     nil,
-				Description: "allow embedding local files relative to this directory",
+		},
+		"platform": schema.StringAttribute{
+			Optional:    true,
+			Description: "platform whose user-data size limit the rendered configuration must fit",
+			Validators: []validator.String{
+				stringvalidator.OneOf(platforms()...),
 			},
-			"pretty_print": {
-				Type:     schema.TypeBool,
-				Optional: true,
-				Default:  false,
+		},
+		"max_size": schema.Int64Attribute{
+			Optional:    true,
+			Description: "maximum size in bytes of the rendered configuration, overriding the platform limit",
+			Validators: []validator.Int64{
+				int64validator.AtLeast(1),
 			},
-			"strict": {
-				Type:     schema.TypeBool,
-				Optional: true,
-				Default:  false,
+		},
+		"max_size_gzip": schema.BoolAttribute{
+			Optional:    true,
+			Computed:    true,
+			Description: "apply the size limit to the gzip compressed rendered configuration",
+		},
+		"pointer_url": schema.StringAttribute{
+			Optional:    true,
+			Description: "URL where full_rendered will be hosted, referenced by pointer_rendered. {sha512} is replaced by the config's hex digest",
+		},
+		"rendered": schema.StringAttribute{
+			Computed:    true,
+			Description: "rendered ignition configuration",
+		},
+		"rendered_base64": schema.StringAttribute{
+			Computed:    true,
+			Description: "base64 encoded rendered ignition configuration",
+		},
+		"rendered_gzip_base64": schema.StringAttribute{
+			Computed:    true,
+			Description: "gzip compressed and base64 encoded rendered ignition configuration",
+		},
+		"sha256": schema.StringAttribute{
+			Computed:    true,
+			Description: "SHA-256 digest of rendered, in Ignition verification.hash format (sha256-<hex>)",
+		},
+		"sha512": schema.StringAttribute{
+			Computed:    true,
+			Description: "SHA-512 digest of rendered, in Ignition verification.hash format (sha512-<hex>)",
+		},
+		"pointer_rendered": schema.StringAttribute{
+			Computed:    true,
+			Description: "ignition configuration that replaces itself with full_rendered fetched from pointer_url",
+		},
+		"full_rendered": schema.StringAttribute{
+			Computed:    true,
+			Description: "full ignition configuration to host at pointer_url",
+		},
+		"warnings": schema.ListAttribute{
+			ElementType: warningType,
+			Computed:    true,
+			Description: "translation warnings from content and snippets",
+		},
+		"files": schema.ListAttribute{
+			ElementType: fileType,
+			Computed:    true,
+			Description: "files of the rendered configuration, with their mode, owner, and group",
+		},
+		"systemd_units": schema.ListAttribute{
+			ElementType: systemdUnitType,
+			Computed:    true,
+			Description: "systemd units of the rendered configuration, with their enabled state",
+		},
+		"users": schema.ListAttribute{
+			ElementType: userType,
+			Computed:    true,
+			Description: "users of the rendered configuration, with their supplementary groups",
+		},
+		"filesystems": schema.ListAttribute{
+			ElementType: filesystemType,
+			Computed:    true,
+			Description: "filesystems of the rendered configuration",
+		},
+		"disks": schema.ListAttribute{
+			ElementType: diskType,
+			Computed:    true,
+			Description: "disks of the rendered configuration, with their partition labels",
+		},
+		"luks": schema.ListAttribute{
+			ElementType: luksType,
+			Computed:    true,
+			Description: "LUKS devices of the rendered configuration",
+		},
+		"explanation": schema.MapAttribute{
+			ElementType: types.StringType,
+			Computed:    true,
+			Description: "input that last set each keyed entry (e.g. file:/etc/hostname) of the rendered configuration (requires explain)",
+		},
+		"explanation_report": schema.StringAttribute{
+			Computed:    true,
+			Description: "human-readable explanation, noting the inputs each entry overrides (requires explain)",
+		},
+	}
+}
+
+// configBlocks returns the blocks shared by the ct_config data source and
+// ephemeral resource.
+func configBlocks() map[string]schema.Block {
+	return map[string]schema.Block{
+		"snippet": schema.ListNestedBlock{
+			Description: "Butane snippet with its own files_dir (e.g. of the module providing it), merged after snippets",
+			NestedObject: schema.NestedBlockObject{
+				Attributes: map[string]schema.Attribute{
+					"content": schema.StringAttribute{
+						Required:    true,
+						Description: "Butane snippet",
+					},
+					"files_dir": schema.StringAttribute{
+						Optional:    true,
+						Description: "allow embedding local files relative to this directory (default: files_dir)",
+					},
+				},
 			},
-			"rendered": {
-				Type:        schema.TypeString,
-				Computed:    true,
-				Description: "rendered ignition configuration",
+		},
+		"trees": schema.ListNestedBlock{
+			Description: "local directory whose files are added to the configuration, merged after the content",
+			NestedObject: schema.NestedBlockObject{
+				Attributes: map[string]schema.Attribute{
+					"local": schema.StringAttribute{
+						Required:    true,
+						Description: "local directory (e.g. relative to path.module)",
+					},
+					"path": schema.StringAttribute{
+						Required:    true,
+						Description: "destination directory of the files",
+					},
+					"include": schema.ListAttribute{
+						ElementType: types.StringType,
+						Optional:    true,
+						Description: "globs of files to include (default: all files)",
+					},
+					"exclude": schema.ListAttribute{
+						ElementType: types.StringType,
+						Optional:    true,
+						Description: "globs of files and directories to exclude",
+					},
+					"modes": schema.MapAttribute{
+						ElementType: types.StringType,
+						Optional:    true,
+						Description: "octal modes (e.g. 0600) of files matching globs (default: 0755 for executable files, 0644 otherwise)",
+					},
+					"max_file_size": schema.Int64Attribute{
+						Optional:    true,
+						Description: "maximum size of each file in bytes (default: 1 MiB)",
+						Validators: []validator.Int64{
+							int64validator.AtLeast(1),
+						},
+					},
+				},
 			},
 		},
 	}
 }
 
-func datasourceConfigRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var diags diag.Diagnostics
+func (d *configDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.meta = req.ProviderData.(*providerMeta)
+}
+
+func (d *configDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var cfg configDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &cfg)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	rendered, err := renderConfig(d)
+	sensitive := d.meta != nil && d.meta.sensitive
+	if !cfg.Sensitive.IsNull() {
+		sensitive = cfg.Sensitive.ValueBool()
+	}
+	if sensitive && cfg.PointerURL.ValueString() != "" {
+		resp.Diagnostics.AddAttributeError(path.Root("pointer_url"), "pointer_url is unsupported with sensitive",
+			"full_rendered would expose the full config in plaintext")
+		return
+	}
+
+	resp.Diagnostics.Append(cfg.render(ctx, d.meta)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	cfg.ID = types.StringValue(hashcode(cfg.Rendered.ValueString()))
+
+	// plaintext outputs of the full config are omitted when sensitive
+	cfg.RenderedSensitive = types.StringNull()
+	if sensitive {
+		cfg.RenderedSensitive = cfg.Rendered
+		cfg.Rendered = types.StringNull()
+		cfg.RenderedBase64 = types.StringNull()
+		cfg.RenderedGzipBase64 = types.StringNull()
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &cfg)...)
+}
+
+// render renders the config and sets the computed attributes shared by the
+// ct_config data source and ephemeral resource.
+func (cfg *configModel) render(ctx context.Context, meta *providerMeta) diag.Diagnostics {
+	// optional computed attributes show their defaults when unset, which are
+	// the provider's for pretty_print and strict
+	defaults := meta
+	if defaults == nil {
+		defaults = &providerMeta{}
+	}
+	if cfg.PrettyPrint.IsNull() {
+		cfg.PrettyPrint = types.BoolValue(defaults.pretty)
+	}
+	if cfg.Strict.IsNull() {
+		cfg.Strict = types.BoolValue(defaults.strict)
+	}
+	if cfg.IgnitionVersion.IsNull() {
+		cfg.IgnitionVersion = types.StringValue(defaultIgnitionVersion)
+	}
+	if cfg.MaxSizeGzip.IsNull() {
+		cfg.MaxSizeGzip = types.BoolValue(false)
+	}
+
+	rendered, warnings, diags := renderConfig(ctx, cfg, meta)
+	if diags.HasError() {
+		return diags
+	}
+	diags.Append(cfg.introspect(ctx, rendered)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	// pointer config replaced by the full config, if the full config is too large
+	cfg.PointerRendered = types.StringNull()
+	cfg.FullRendered = types.StringNull()
+	if url := cfg.PointerURL.ValueString(); url != "" {
+		pointer, err := pointerConfig(url, []byte(rendered), ignitionSpecs[cfg.IgnitionVersion.ValueString()])
+		if err != nil {
+			diags.AddError("pointer config error", err.Error())
+			return diags
+		}
+		cfg.PointerRendered = types.StringValue(string(pointer))
+		cfg.FullRendered = types.StringValue(rendered)
+
+		size, err := configSize(cfg, rendered)
+		if err != nil {
+			diags.AddError("gzip compress error", err.Error())
+			return diags
+		}
+		if limit := maxSize(cfg); limit > 0 && size > limit {
+			rendered = string(pointer)
+		}
+	}
+
+	size, err := configSize(cfg, rendered)
 	if err != nil {
-		return diag.FromErr(err)
+		diags.AddError("gzip compress error", err.Error())
+		return diags
+	}
+	if limit := maxSize(cfg); limit > 0 && size > limit {
+		return append(diags, sizeDiagnostic(cfg, size, limit))
 	}
 
-	if err := d.Set("rendered", rendered); err != nil {
-		return diag.FromErr(err)
+	compressed, err := gzipCompress([]byte(rendered))
+	if err != nil {
+		diags.AddError("gzip compress error", err.Error())
+		return diags
 	}
-	d.SetId(hashcode(rendered))
+	cfg.Rendered = types.StringValue(rendered)
+	cfg.RenderedBase64 = types.StringValue(base64.StdEncoding.EncodeToString([]byte(rendered)))
+	cfg.RenderedGzipBase64 = types.StringValue(base64.StdEncoding.EncodeToString(compressed))
+	cfg.SHA256 = types.StringValue(sha256Digest([]byte(rendered)))
+	cfg.SHA512 = types.StringValue(sha512Digest([]byte(rendered)))
+	cfg.Warnings = flattenWarnings(warnings)
 	return diags
 }
 
 // Render a Fedora CoreOS Config or Container Linux Config as Ignition JSON.
-func renderConfig(d *schema.ResourceData) (string, error) {
-	// unchecked assertions seem to be the norm in Terraform :S
-	content := d.Get("content").(string)
-	pretty := d.Get("pretty_print").(bool)
-	filesDir := d.Get("files_dir").(string)
-	strict := d.Get("strict").(bool)
-	snippetsIface := d.Get("snippets").([]interface{})
-
-	snippets := make([]string, len(snippetsIface))
-	for i, v := range snippetsIface {
-		if v != nil {
-			snippets[i] = v.(string)
+// Attributes left unset fall back to the provider-level defaults. If explain
+// is set, the explanation attributes are set too.
+func renderConfig(ctx context.Context, cfg *configModel, meta *providerMeta) (string, []warning, diag.Diagnostics) {
+	if meta == nil {
+		meta = &providerMeta{}
+	}
+	content := cfg.Content.ValueString()
+	pretty := meta.pretty
+	if !cfg.PrettyPrint.IsNull() {
+		pretty = cfg.PrettyPrint.ValueBool()
+	}
+	filesDir := meta.filesDir
+	if !cfg.FilesDir.IsNull() {
+		filesDir = cfg.FilesDir.ValueString()
+	}
+	strict := meta.strict
+	if !cfg.Strict.IsNull() {
+		strict = cfg.Strict.ValueBool()
+	}
+	lint := cfg.Lint.ValueBool()
+	var snippets []snippet
+	for i, content := range meta.snippets {
+		snippets = append(snippets, snippet{content: content, in: snippetInput(i)})
+	}
+	if !cfg.Snippets.IsNull() {
+		snippets = nil
+		for i, content := range listStrings(cfg.Snippets) {
+			snippets = append(snippets, snippet{content: content, in: snippetInput(i)})
 		}
 	}
+	for i, m := range cfg.SnippetBlocks {
+		snippets = append(snippets, snippet{content: m.Content.ValueString(), filesDir: m.FilesDir.ValueString(), in: snippetBlockInput(i)})
+	}
+	trees, diags := parseTrees(cfg.Trees)
+	if diags.HasError() {
+		return "", nil, diags
+	}
+	// trees are merged after the content, so snippets can override their files
+	fromTrees, diags := treeSnippets(trees, content)
+	if diags.HasError() {
+		return "", nil, diags
+	}
+	snippets = append(fromTrees, snippets...)
+	named, err := orderNamedSnippets(mapStrings(cfg.NamedSnippets), listStrings(cfg.NamedSnippetsOrder))
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddAttributeError(path.Root("named_snippets_order"), "invalid named_snippets_order", err.Error())
+		return "", nil, diags
+	}
+	snippets = append(snippets, named...)
+	var ignitionSnippets []snippet
+	for i, content := range listStrings(cfg.IgnitionSnippets) {
+		ignitionSnippets = append(ignitionSnippets, snippet{content: content, in: listInput("ignition_snippets", i)})
+	}
+	spec := ignitionSpecs[cfg.IgnitionVersion.ValueString()]
 
 	// Butane Config
-	ign, err := butaneToIgnition([]byte(content), pretty, filesDir, strict, snippets)
-	return string(ign), err
+	var explanation *[]explained
+	if cfg.Explain.ValueBool() {
+		explanation = &[]explained{}
+	}
+	ign, warnings, diags := butaneToIgnition([]byte(content), pretty, filesDir, strict, lint, cfg.ConflictPolicy.ValueString(), snippets, ignitionSnippets, spec, explanation)
+	cfg.Explanation, cfg.ExplanationReport = types.MapNull(types.StringType), types.StringNull()
+	if explanation != nil && !diags.HasError() {
+		cfg.Explanation, cfg.ExplanationReport = flattenExplanation(*explanation)
+	}
+	return string(ign), warnings, diags
+}
+
+// snippetModel is a snippet block.
+type snippetModel struct {
+	Content  types.String `tfsdk:"content"`
+	FilesDir types.String `tfsdk:"files_dir"`
+}
+
+// snippet is a config merged into the content, with the input it came from.
+type snippet struct {
+	content string
+	in      input
+	// filesDir overrides files_dir for the snippet
+	filesDir string
+}
+
+// parseSnippets reads Butane snippets from a list of configs or of objects
+// with content and an optional files_dir (e.g. of the module providing the
+// snippet), which may be mixed, as the butane_to_ignition function's snippets
+// option allows.
+func parseSnippets(value tftypes.Value) ([]snippet, error) {
+	var elems []tftypes.Value
+	if err := value.As(&elems); err != nil {
+		return nil, fmt.Errorf("expected a list: %v", err)
+	}
+	var snippets []snippet
+	for i, elem := range elems {
+		s := snippet{in: snippetInput(i)}
+		if elem.Type().Is(tftypes.String) {
+			if err := elem.As(&s.content); err != nil {
+				return nil, fmt.Errorf("%s: %v", s.in.name, err)
+			}
+			snippets = append(snippets, s)
+			continue
+		}
+
+		var attrs map[string]tftypes.Value
+		if err := elem.As(&attrs); err != nil {
+			return nil, fmt.Errorf("%s must be a string or an object with content and files_dir", s.in.name)
+		}
+		for name, attr := range attrs {
+			var err error
+			switch name {
+			case "content":
+				err = attr.As(&s.content)
+			case "files_dir":
+				err = attr.As(&s.filesDir)
+			default:
+				err = fmt.Errorf("unsupported attribute")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid %s: %v", s.in.name, name, err)
+			}
+		}
+		if attrs["content"].IsNull() {
+			return nil, fmt.Errorf("%s: content is required", s.in.name)
+		}
+		snippets = append(snippets, s)
+	}
+	return snippets, nil
+}
+
+// orderNamedSnippets returns named snippets in merge order, which is the
+// explicit order if given (listing every name once) or sorted by name.
+func orderNamedSnippets(named map[string]string, order []string) ([]snippet, error) {
+	if len(order) == 0 {
+		for name := range named {
+			order = append(order, name)
+		}
+		sort.Strings(order)
+	} else if len(order) != len(named) {
+		return nil, fmt.Errorf("named_snippets_order must list each of the %d named_snippets exactly once", len(named))
+	}
+
+	snippets := make([]snippet, len(order))
+	seen := map[string]bool{}
+	for i, name := range order {
+		content, ok := named[name]
+		if !ok {
+			return nil, fmt.Errorf("named_snippets_order lists %q, which isn't in named_snippets", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("named_snippets_order lists %q more than once", name)
+		}
+		seen[name] = true
+		snippets[i] = snippet{content: content, in: namedSnippetInput(name)}
+	}
+	return snippets, nil
 }
 
 // Translate Fedora CoreOS config to Ignition v3.X.Y
-func butaneToIgnition(data []byte, pretty bool, filesDir string, strict bool, snippets []string) ([]byte, error) {
+func butaneToIgnition(data []byte, pretty bool, filesDir string, strict, lint bool, conflictPolicy string, snippets, ignitionSnippets []snippet, spec ignitionSpec, explanation *[]explained) ([]byte, []warning, diag.Diagnostics) {
 	ignBytes, report, err := butane.TranslateBytes(data, common.TranslateBytesOptions{
 		TranslateOptions: common.TranslateOptions{
 			FilesDir: filesDir,
 		},
 		Pretty: pretty,
 	})
+	diags := reportDiagnostics(report, contentInput, strict)
 	// ErrNoVariant indicates data is a CLC, not an FCC
 	if err != nil {
-		return nil, err
+		return nil, nil, append(diags, contentInput.errorDiagnostic("Butane translate error", err))
 	}
-	if strict && len(report.Entries) > 0 {
-		return nil, fmt.Errorf("strict parsing error: %v", report.String())
+	if diags.HasError() {
+		return nil, nil, diags
 	}
+	warnings := reportWarnings(report, contentInput)
 
 	// merge FCC snippets into main Ignition config
-	return mergeFCCSnippets(ignBytes, pretty, filesDir, strict, snippets)
+	ign, snippetWarnings, snippetDiags := mergeFCCSnippets(ignBytes, pretty, filesDir, strict, lint, conflictPolicy, snippets, ignitionSnippets, spec, explanation)
+	return ign, append(warnings, snippetWarnings...), append(diags, snippetDiags...)
 }
 
-// Parse Fedora CoreOS Ignition and Butane snippets into Ignition Config.
-func mergeFCCSnippets(ignBytes []byte, pretty bool, filesDir string, strict bool, snippets []string) ([]byte, error) {
-	ign, _, err := ignition.ParseCompatibleVersion(ignBytes)
+// Parse Fedora CoreOS Ignition, Butane snippets, and Ignition snippets into
+// an Ignition Config of the given spec version. If lint, the merged config
+// is checked for common mistakes. Inputs that set the same keyed entry
+// differently are reported by conflictPolicy (allow, warn, or error). If
+// explanation is non-nil, it's set to the input that last set each keyed
+// entry of the merged config.
+func mergeFCCSnippets(ignBytes []byte, pretty bool, filesDir string, strict, lint bool, conflictPolicy string, snippets, ignitionSnippets []snippet, spec ignitionSpec, explanation *[]explained) ([]byte, []warning, diag.Diagnostics) {
+	var warnings []warning
+	var diags diag.Diagnostics
+
+	ign, _, err := spec.Parse(ignBytes)
 	if err != nil {
-		return nil, fmt.Errorf("%v", err)
+		return nil, nil, append(diags, contentInput.errorDiagnostic("Ignition parse error", err))
 	}
+	sources := []sourceConfig{{in: contentInput, config: ign}}
 
 	for _, snippet := range snippets {
-		ignextBytes, report, err := butane.TranslateBytes([]byte(snippet), common.TranslateBytesOptions{
+		in := snippet.in
+		snippetFilesDir := filesDir
+		if snippet.filesDir != "" {
+			snippetFilesDir = snippet.filesDir
+		}
+		ignextBytes, report, err := butane.TranslateBytes([]byte(snippet.content), common.TranslateBytesOptions{
 			TranslateOptions: common.TranslateOptions{
-				FilesDir: filesDir,
+				FilesDir: snippetFilesDir,
 			},
 			Pretty: pretty,
 		})
+		diags = append(diags, reportDiagnostics(report, in, strict)...)
 		if err != nil {
 			// For FCC, require snippets be FCCs (don't fall-through to CLC)
 			if err == common.ErrNoVariant {
-				return nil, fmt.Errorf("Butane snippets require `variant`: %v", err)
+				return nil, nil, append(diags, in.errorDiagnostic("Butane snippets require `variant`", err))
 			}
-			return nil, fmt.Errorf("Butane translate error: %v\n%s", err, report.String())
+			return nil, nil, append(diags, in.errorDiagnostic("Butane translate error", err))
 		}
-		if strict && len(report.Entries) > 0 {
-			return nil, fmt.Errorf("strict parsing error: %v", report.String())
+		if diags.HasError() {
+			return nil, nil, diags
 		}
+		warnings = append(warnings, reportWarnings(report, in)...)
+
+		ignext, _, err := spec.Parse(ignextBytes)
+		if err != nil {
+			return nil, nil, append(diags, in.errorDiagnostic("snippet parse error", err))
+		}
+		sources = append(sources, sourceConfig{in: in, config: ignext})
+		ign = spec.Merge(ign, ignext)
+	}
 
-		ignext, _, err := ignition.ParseCompatibleVersion(ignextBytes)
+	// Ignition snippets skip Butane translation
+	for _, snippet := range ignitionSnippets {
+		in := snippet.in
+		ignext, report, err := spec.Parse([]byte(snippet.content))
+		diags = append(diags, reportDiagnostics(report, in, strict)...)
 		if err != nil {
-			return nil, fmt.Errorf("snippet parse error: %v, expect v1.4.0", err)
+			return nil, nil, append(diags, in.errorDiagnostic("Ignition snippet parse error", err))
 		}
-		ign = ignition.Merge(ign, ignext)
+		if diags.HasError() {
+			return nil, nil, diags
+		}
+		warnings = append(warnings, reportWarnings(report, in)...)
+		sources = append(sources, sourceConfig{in: in, config: ignext})
+		ign = spec.Merge(ign, ignext)
 	}
 
-	return marshalJSON(ign, pretty)
+	if lint {
+		issues, err := lintConfig(ign, sources)
+		if err != nil {
+			diags.AddError("lint error", err.Error())
+			return nil, nil, diags
+		}
+		diags = append(diags, lintDiagnostics(issues, strict)...)
+		if diags.HasError() {
+			return nil, nil, diags
+		}
+		warnings = append(warnings, lintWarnings(issues)...)
+	}
+	if conflictPolicy == conflictWarn || conflictPolicy == conflictError {
+		conflicts, err := findConflicts(sources)
+		if err != nil {
+			diags.AddError("conflict error", err.Error())
+			return nil, nil, diags
+		}
+		diags = append(diags, conflictDiagnostics(conflicts, conflictPolicy)...)
+		if diags.HasError() {
+			return nil, nil, diags
+		}
+		warnings = append(warnings, conflictWarnings(conflicts)...)
+	}
+	if explanation != nil {
+		*explanation, err = explainConfig(sources)
+		if err != nil {
+			diags.AddError("explain error", err.Error())
+			return nil, nil, diags
+		}
+	}
+
+	ignBytes, err = marshalJSON(ign, pretty)
+	if err != nil {
+		diags.AddError("Ignition marshal error", err.Error())
+		return nil, nil, diags
+	}
+	return ignBytes, warnings, diags
 }
 
 func marshalJSON(v interface{}, pretty bool) ([]byte, error) {
@@ -152,3 +735,20 @@ func marshalJSON(v interface{}, pretty bool) ([]byte, error) {
 	}
 	return json.Marshal(v)
 }
+
+// gzipCompress gzip compresses data, as accepted by cloud user-data that
+// decompresses Ignition.
+func gzipCompress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
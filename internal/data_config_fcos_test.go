@@ -1,10 +1,21 @@
 package internal
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
 	r "github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 // Fedora CoreOS variant, v1.5.0
@@ -88,12 +99,14 @@ EOT
 
 func TestButaneConfig_FCOSv1_5(t *testing.T) {
 	r.UnitTest(t, r.TestCase{
-		Providers: testProviders,
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
 		Steps: []r.TestStep{
 			{
 				Config: fedoraCoreOSV15Resource,
 				Check: r.ComposeTestCheckFunc(
 					r.TestCheckResourceAttr("data.ct_config.fedora-coreos", "rendered", ignitionV34Expected),
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos", "ignition_version", "3.4.0"),
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos", "max_size_gzip", "false"),
 				),
 			},
 			{
@@ -273,7 +286,7 @@ const ignitionV34WithSnippetsPrettyFalseExpected = `{"ignition":{"config":{"repl
 
 func TestButaneConfig_FCOSv1_4(t *testing.T) {
 	r.UnitTest(t, r.TestCase{
-		Providers: testProviders,
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
 		Steps: []r.TestStep{
 			{
 				Config: fedoraCoreOSV14Resource,
@@ -378,7 +391,7 @@ EOT
 
 func TestButaneConfig_FCOSv1_3(t *testing.T) {
 	r.UnitTest(t, r.TestCase{
-		Providers: testProviders,
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
 		Steps: []r.TestStep{
 			{
 				Config: fedoraCoreOSV13Resource,
@@ -483,7 +496,7 @@ EOT
 
 func TestButaneConfig_FCOSv1_2(t *testing.T) {
 	r.UnitTest(t, r.TestCase{
-		Providers: testProviders,
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
 		Steps: []r.TestStep{
 			{
 				Config: fedoraCoreOSV12Resource,
@@ -614,7 +627,7 @@ EOT
 
 func TestButaneConfig_FCOSv1_1(t *testing.T) {
 	r.UnitTest(t, r.TestCase{
-		Providers: testProviders,
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
 		Steps: []r.TestStep{
 			{
 				Config: fedoraCoreOSV11Resource,
@@ -715,7 +728,7 @@ EOT
 
 func TestButaneConfig_FCOSv1_0(t *testing.T) {
 	r.UnitTest(t, r.TestCase{
-		Providers: testProviders,
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
 		Steps: []r.TestStep{
 			{
 				Config: fedoraCoreOSV10Resource,
@@ -805,7 +818,7 @@ const ignitionV34MixExpected = `{
 
 func TestFedoraCoreOSMix_SnippetBehind(t *testing.T) {
 	r.UnitTest(t, r.TestCase{
-		Providers: testProviders,
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
 		Steps: []r.TestStep{
 			{
 				Config: fedoraCoreOSMixSnippetBehind,
@@ -847,7 +860,7 @@ EOT
 
 func TestFedoraCoreOSMixVersions_SnippetAhead(t *testing.T) {
 	r.UnitTest(t, r.TestCase{
-		Providers: testProviders,
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
 		Steps: []r.TestStep{
 			{
 				Config: fedoraCoreOSMixSnippetAhead,
@@ -869,7 +882,7 @@ data "ct_config" "invalid" {
 
 func TestInvalidResource(t *testing.T) {
 	r.UnitTest(t, r.TestCase{
-		Providers: testProviders,
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
 		Steps: []r.TestStep{
 			{
 				Config:      invalidResource,
@@ -878,3 +891,1431 @@ func TestInvalidResource(t *testing.T) {
 		},
 	})
 }
+
+// Ignition spec version selection
+
+const fedoraCoreOSIgnitionV33 = `
+data "ct_config" "fedora-coreos-ignition-version" {
+  strict = true
+  ignition_version = "3.3.0"
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+EOT
+}
+`
+
+const ignitionV33Expected = `{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.3.0"},"kernelArguments":{},"passwd":{"users":[{"name":"core"}]},"storage":{},"systemd":{}}`
+
+const fedoraCoreOSIgnitionV35 = `
+data "ct_config" "fedora-coreos-ignition-version" {
+  strict = true
+  ignition_version = "3.5.0"
+  content = <<EOT
+---
+variant: fcos
+version: 1.6.0
+passwd:
+  users:
+    - name: core
+EOT
+}
+`
+
+const ignitionV35Expected = `{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{},"passwd":{"users":[{"name":"core"}]},"storage":{},"systemd":{}}`
+
+const fedoraCoreOSIgnitionUnavailableField = `
+data "ct_config" "fedora-coreos-ignition-version" {
+  strict = true
+  ignition_version = "3.3.0"
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+storage:
+  luks:
+    - name: data
+      device: /dev/vdb
+      discard: true
+EOT
+}
+`
+
+// Ignition snippets newer than ignition_version are downgraded
+const fedoraCoreOSIgnitionDowngradeStrict = `
+data "ct_config" "fedora-coreos-ignition-version" {
+  strict = true
+  ignition_version = "3.0.0"
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+EOT
+  ignition_snippets = [
+    jsonencode({
+      ignition = { version = "3.5.0" }
+      storage = { files = [{ path = "/etc/motd", contents = { source = "data:,hello" } }] }
+    })
+  ]
+}
+`
+
+// Warnings of Ignition snippets newer than ignition_version are kept
+const fedoraCoreOSIgnitionDowngradeUnknownKey = `
+data "ct_config" "fedora-coreos-ignition-version" {
+  strict = true
+  ignition_version = "3.3.0"
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+  ignition_snippets = [
+    jsonencode({
+      ignition = { version = "3.4.0" }
+      unknown_key = true
+    })
+  ]
+}
+`
+
+// List elements that are empty once pruned are dropped when downgrading
+const fedoraCoreOSIgnitionDowngradeEmptyElement = `
+data "ct_config" "fedora-coreos-ignition-version" {
+  strict = true
+  ignition_version = "3.3.0"
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+  ignition_snippets = [
+    jsonencode({
+      ignition = { version = "3.5.0" }
+      storage = { files = [{ path = "/etc/motd", append = [{}, { source = "data:,hello" }] }] }
+    })
+  ]
+}
+`
+
+func TestFedoraCoreOSIgnitionVersion(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: fedoraCoreOSIgnitionV33,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-ignition-version", "rendered", ignitionV33Expected),
+				),
+			},
+			{
+				Config: fedoraCoreOSIgnitionV35,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-ignition-version", "rendered", ignitionV35Expected),
+				),
+			},
+			{
+				// empty fields of the newer spec aren't unused keys
+				Config: fedoraCoreOSIgnitionDowngradeStrict,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-ignition-version", "warnings.#", "0"),
+					r.TestMatchResourceAttr("data.ct_config.fedora-coreos-ignition-version", "rendered", regexp.MustCompile(`"version":"3.0.0"`)),
+				),
+			},
+			{
+				Config: fedoraCoreOSIgnitionDowngradeEmptyElement,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-ignition-version", "warnings.#", "0"),
+					r.TestMatchResourceAttr("data.ct_config.fedora-coreos-ignition-version", "rendered", regexp.MustCompile(`"append":\[\{"source":"data:,hello"`)),
+				),
+			},
+			{
+				Config:      fedoraCoreOSIgnitionDowngradeUnknownKey,
+				ExpectError: regexp.MustCompile(`strict parsing\s+error: unused key unknown_key`),
+			},
+			{
+				Config:      fedoraCoreOSIgnitionUnavailableField,
+				ExpectError: regexp.MustCompile(`unavailable in Ignition 3.3.0:\s+storage.luks\[0\].discard`),
+			},
+		},
+	})
+}
+
+// Validation diagnostics
+
+const fedoraCoreOSStrictSnippet = `
+data "ct_config" "fedora-coreos-strict" {
+  strict = true
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+  snippets = [
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+,
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      unknown_key: true
+EOT
+  ]
+}
+`
+
+const fedoraCoreOSNonStrictSnippet = `
+data "ct_config" "fedora-coreos-strict" {
+  strict = false
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+unknown_key: true
+EOT
+  snippets = [
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      unknown_key: true
+EOT
+  ]
+}
+`
+
+func TestFedoraCoreOSDiagnostics(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config:      fedoraCoreOSStrictSnippet,
+				ExpectError: regexp.MustCompile(`snippets\[1\]: warning at \$.passwd.users.0.unknown_key, line 7 col 7`),
+			},
+			{
+				Config: fedoraCoreOSNonStrictSnippet,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrSet("data.ct_config.fedora-coreos-strict", "rendered"),
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-strict", "warnings.#", "2"),
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-strict", "warnings.0.message", "unused key unknown_key"),
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-strict", "warnings.0.path", "$.unknown_key"),
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-strict", "warnings.0.source", "content"),
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-strict", "warnings.1.path", "$.systemd.units.0.unknown_key"),
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-strict", "warnings.1.source", "snippets[0]"),
+				),
+			},
+		},
+	})
+}
+
+// Ignition snippets
+
+const fedoraCoreOSIgnitionSnippets = `
+data "ct_config" "fedora-coreos-ignition-snippets" {
+  strict = true
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - key
+EOT
+  ignition_snippets = [
+<<EOT
+{"ignition":{"version":"3.3.0"},"systemd":{"units":[{"name":"docker.service","enabled":true}]}}
+EOT
+  ]
+}
+`
+
+const fedoraCoreOSIgnitionSnippetsInvalid = `
+data "ct_config" "fedora-coreos-ignition-snippets" {
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+  ignition_snippets = [
+<<EOT
+{"ignition":{"version":"9.9.9"}}
+EOT
+  ]
+}
+`
+
+func TestFedoraCoreOSIgnitionSnippets(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: fedoraCoreOSIgnitionSnippets,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-ignition-snippets", "rendered", ignitionV34WithSnippetsPrettyFalseExpected),
+				),
+			},
+			{
+				Config:      fedoraCoreOSIgnitionSnippetsInvalid,
+				ExpectError: regexp.MustCompile(`ignition_snippets\[0\]: unsupported config version`),
+			},
+		},
+	})
+}
+
+// Named snippets
+
+const fedoraCoreOSNamedSnippets = `
+data "ct_config" "fedora-coreos-named-snippets" {
+  strict = true
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+  named_snippets = {
+    users = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - key
+EOT
+    units = <<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      enabled: true
+EOT
+  }
+  named_snippets_order = ["units", "users"]
+}
+`
+
+const fedoraCoreOSNamedSnippetsError = `
+data "ct_config" "fedora-coreos-named-snippets" {
+  strict = true
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+  named_snippets = {
+    units = <<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      unknown_key: true
+EOT
+  }
+}
+`
+
+const fedoraCoreOSNamedSnippetsBadOrder = `
+data "ct_config" "fedora-coreos-named-snippets" {
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+  named_snippets = {
+    units = "variant: fcos\nversion: 1.5.0"
+  }
+  named_snippets_order = ["users"]
+}
+`
+
+func TestFedoraCoreOSNamedSnippets(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: fedoraCoreOSNamedSnippets,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-named-snippets", "rendered", ignitionV34WithSnippetsPrettyFalseExpected),
+				),
+			},
+			{
+				Config:      fedoraCoreOSNamedSnippetsError,
+				ExpectError: regexp.MustCompile(`named_snippets\["units"\]: warning at \$.systemd.units.0.unknown_key`),
+			},
+			{
+				Config:      fedoraCoreOSNamedSnippetsBadOrder,
+				ExpectError: regexp.MustCompile(`named_snippets_order lists "users", which isn't in named_snippets`),
+			},
+		},
+	})
+}
+
+// Encoded outputs and digests
+
+func TestFedoraCoreOSEncodedOutputs(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: fedoraCoreOSV15WithSnippetsPrettyFalse,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-snippets", "rendered_base64", base64.StdEncoding.EncodeToString([]byte(ignitionV34WithSnippetsPrettyFalseExpected))),
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-snippets", "sha256", fmt.Sprintf("sha256-%x", sha256.Sum256([]byte(ignitionV34WithSnippetsPrettyFalseExpected)))),
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-snippets", "sha512", fmt.Sprintf("sha512-%x", sha512.Sum512([]byte(ignitionV34WithSnippetsPrettyFalseExpected)))),
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-snippets", "id", fmt.Sprintf("%x", sha256.Sum256([]byte(ignitionV34WithSnippetsPrettyFalseExpected)))),
+					r.TestCheckResourceAttrWith("data.ct_config.fedora-coreos-snippets", "rendered_gzip_base64", func(value string) error {
+						compressed, err := base64.StdEncoding.DecodeString(value)
+						if err != nil {
+							return err
+						}
+						zr, err := gzip.NewReader(bytes.NewReader(compressed))
+						if err != nil {
+							return err
+						}
+						rendered, err := io.ReadAll(zr)
+						if err != nil {
+							return err
+						}
+						if string(rendered) != ignitionV34WithSnippetsPrettyFalseExpected {
+							return fmt.Errorf("decompressed %q, expected %q", rendered, ignitionV34WithSnippetsPrettyFalseExpected)
+						}
+						return nil
+					}),
+				),
+			},
+		},
+	})
+}
+
+// Size limits
+
+const fedoraCoreOSSizeLimit = `
+data "ct_config" "fedora-coreos-size" {
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - key
+EOT
+  snippets = [
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      enabled: true
+EOT
+  ]
+  %s
+}
+`
+
+func TestFedoraCoreOSSizeLimit(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(fedoraCoreOSSizeLimit, `platform = "aws"`),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-size", "rendered", ignitionV34WithSnippetsPrettyFalseExpected),
+				),
+			},
+			{
+				Config:      fmt.Sprintf(fedoraCoreOSSizeLimit, `max_size = 250`),
+				ExpectError: regexp.MustCompile(`rendered config is 282 bytes, exceeding the max_size of 250 bytes`),
+			},
+			{
+				Config: fmt.Sprintf(fedoraCoreOSSizeLimit, "max_size = 250\n  max_size_gzip = true"),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.fedora-coreos-size", "rendered", ignitionV34WithSnippetsPrettyFalseExpected),
+				),
+			},
+		},
+	})
+}
+
+// about 60 KiB rendered, which exceeds 64 KiB once base64 encoded
+const fedoraCoreOSSizeLimitBase64 = `
+data "ct_config" "fedora-coreos-size" {
+  content = yamlencode({
+    variant = "fcos"
+    version = "1.5.0"
+    passwd = {
+      users = [{
+        name                = "core"
+        ssh_authorized_keys = [for i in range(1000) : format("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKEY%%04d core@example.com", i)]
+      }]
+    }
+  })
+  %s
+}
+`
+
+func TestFedoraCoreOSSizeLimitBase64(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config:      fmt.Sprintf(fedoraCoreOSSizeLimitBase64, `platform = "openstack"`),
+				ExpectError: regexp.MustCompile(`base64 encoded rendered config is \d+ bytes, exceeding the\s+openstack\s+user-data\s+limit of 65535 bytes`),
+			},
+			{
+				Config:      fmt.Sprintf(fedoraCoreOSSizeLimitBase64, `platform = "azure"`),
+				ExpectError: regexp.MustCompile(`base64 encoded rendered config is \d+ bytes, exceeding the\s+azure\s+user-data\s+limit of 65536 bytes`),
+			},
+			{
+				Config: fmt.Sprintf(fedoraCoreOSSizeLimitBase64, `platform = "digitalocean"`),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrSet("data.ct_config.fedora-coreos-size", "rendered"),
+				),
+			},
+			{
+				// max_size applies to rendered, even with a platform
+				Config: fmt.Sprintf(fedoraCoreOSSizeLimitBase64, "platform = \"openstack\"\n  max_size = 65535"),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrSet("data.ct_config.fedora-coreos-size", "rendered"),
+				),
+			},
+		},
+	})
+}
+
+// Pointer configs
+
+const fedoraCoreOSPointer = `
+data "ct_config" "fedora-coreos-pointer" {
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+storage:
+  files:
+    - path: /etc/motd
+      contents:
+        inline: ${join(" ", [for i in range(40) : sha256(tostring(i))])}
+EOT
+  pointer_url = "https://example.com/ignition/{sha512}.ign"
+  %s
+}
+`
+
+func TestFedoraCoreOSPointer(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: fmt.Sprintf(fedoraCoreOSPointer, `max_size = 1024`),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrPair("data.ct_config.fedora-coreos-pointer", "rendered", "data.ct_config.fedora-coreos-pointer", "pointer_rendered"),
+					r.TestMatchResourceAttr("data.ct_config.fedora-coreos-pointer", "pointer_rendered", regexp.MustCompile(`"replace":\{"source":"https://example.com/ignition/([0-9a-f]{128}).ign","verification":\{"hash":"sha512-([0-9a-f]{128})"\}\}`)),
+					r.TestMatchResourceAttr("data.ct_config.fedora-coreos-pointer", "full_rendered", regexp.MustCompile(`"path":"/etc/motd"`)),
+					testCheckPointerHash("data.ct_config.fedora-coreos-pointer"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(fedoraCoreOSPointer, `max_size = 4096`),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrPair("data.ct_config.fedora-coreos-pointer", "rendered", "data.ct_config.fedora-coreos-pointer", "full_rendered"),
+				),
+			},
+		},
+	})
+}
+
+// testCheckPointerHash checks the pointer config verifies the full config.
+func testCheckPointerHash(name string) r.TestCheckFunc {
+	return func(s *terraform.State) error {
+		attrs := s.RootModule().Resources[name].Primary.Attributes
+		sum := sha512.Sum512([]byte(attrs["full_rendered"]))
+		hash := fmt.Sprintf(`"hash":"sha512-%x"`, sum)
+		if !strings.Contains(attrs["pointer_rendered"], hash) {
+			return fmt.Errorf("pointer_rendered %q does not contain %s", attrs["pointer_rendered"], hash)
+		}
+		return nil
+	}
+}
+
+// ct_config attributes available in the last SDKv2 release
+const fedoraCoreOSUpgrade = `
+data "ct_config" "upgrade" {
+  pretty_print = false
+  strict = true
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - key
+EOT
+  snippets = [
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      enabled: true
+EOT
+  ]
+}
+
+# pretty_print and strict left unset, which the SDKv2 ct_config stored as false
+data "ct_config" "defaults" {
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - key
+EOT
+  snippets = [
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      enabled: true
+EOT
+  ]
+}
+
+output "rendered" {
+  value = data.ct_config.upgrade.rendered
+}
+`
+
+// TestFedoraCoreOSUpgrade checks that upgrading from the SDKv2 ct_config to
+// the framework ct_config renders the same config, without a plan diff.
+func TestFedoraCoreOSUpgrade(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		Steps: []r.TestStep{
+			{
+				ExternalProviders: map[string]r.ExternalProvider{
+					"ct": {
+						Source:            "poseidon/ct",
+						VersionConstraint: "0.14.0",
+					},
+				},
+				Config: fedoraCoreOSUpgrade,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.upgrade", "rendered", ignitionV34WithSnippetsPrettyFalseExpected),
+					r.TestCheckResourceAttr("data.ct_config.defaults", "pretty_print", "false"),
+					r.TestCheckResourceAttr("data.ct_config.defaults", "strict", "false"),
+					r.TestCheckResourceAttr("data.ct_config.defaults", "rendered", ignitionV34WithSnippetsPrettyFalseExpected),
+				),
+			},
+			{
+				ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+				Config:                   fedoraCoreOSUpgrade,
+				PlanOnly:                 true,
+			},
+			{
+				ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+				Config:                   fedoraCoreOSUpgrade,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.upgrade", "rendered", ignitionV34WithSnippetsPrettyFalseExpected),
+					r.TestCheckResourceAttr("data.ct_config.upgrade", "ignition_version", defaultIgnitionVersion),
+					r.TestCheckResourceAttr("data.ct_config.upgrade", "id", hashcode(ignitionV34WithSnippetsPrettyFalseExpected)),
+					r.TestCheckResourceAttr("data.ct_config.defaults", "pretty_print", "false"),
+					r.TestCheckResourceAttr("data.ct_config.defaults", "strict", "false"),
+					r.TestCheckResourceAttr("data.ct_config.defaults", "rendered", ignitionV34WithSnippetsPrettyFalseExpected),
+				),
+			},
+		},
+	})
+}
+
+const fedoraCoreOSSensitive = `
+data "ct_config" "sensitive" {
+  pretty_print = false
+  strict = true
+  sensitive = true
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - key
+EOT
+  snippets = [
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      enabled: true
+EOT
+  ]
+}
+`
+
+const fedoraCoreOSSensitiveProviderDefault = `
+provider "ct" {
+  sensitive = true
+}
+
+data "ct_config" "default" {
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+}
+
+data "ct_config" "override" {
+  sensitive = false
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+}
+`
+
+const fedoraCoreOSSensitivePointer = `
+data "ct_config" "sensitive" {
+  sensitive = true
+  pointer_url = "https://example.com/{sha512}.ign"
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+}
+`
+
+func TestFedoraCoreOSSensitive(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: fedoraCoreOSSensitive,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.sensitive", "rendered_sensitive", ignitionV34WithSnippetsPrettyFalseExpected),
+					r.TestCheckNoResourceAttr("data.ct_config.sensitive", "rendered"),
+					r.TestCheckNoResourceAttr("data.ct_config.sensitive", "rendered_base64"),
+					r.TestCheckNoResourceAttr("data.ct_config.sensitive", "rendered_gzip_base64"),
+					r.TestCheckResourceAttr("data.ct_config.sensitive", "sha512", sha512Digest([]byte(ignitionV34WithSnippetsPrettyFalseExpected))),
+				),
+			},
+			{
+				Config: fedoraCoreOSSensitiveProviderDefault,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrSet("data.ct_config.default", "rendered_sensitive"),
+					r.TestCheckNoResourceAttr("data.ct_config.default", "rendered"),
+					r.TestCheckResourceAttrSet("data.ct_config.override", "rendered"),
+					r.TestCheckNoResourceAttr("data.ct_config.override", "rendered_sensitive"),
+				),
+			},
+			{
+				Config:      fedoraCoreOSSensitivePointer,
+				ExpectError: regexp.MustCompile(`pointer_url is unsupported with sensitive`),
+			},
+		},
+	})
+}
+
+const fedoraCoreOSIntrospection = `
+data "ct_config" "introspect" {
+  strict = true
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      groups:
+        - wheel
+        - docker
+storage:
+  disks:
+    - device: /dev/vdb
+      partitions:
+        - label: data
+        - number: 2
+  luks:
+    - name: data
+      device: /dev/disk/by-partlabel/data
+  filesystems:
+    - device: /dev/mapper/data
+      format: xfs
+      path: /var/data
+      with_mount_unit: true
+  files:
+    - path: /etc/hostname
+      mode: 0644
+      user:
+        name: core
+      group:
+        id: 1000
+      contents:
+        inline: node1
+EOT
+  snippets = [
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      enabled: true
+    - name: etcd.service
+      contents: |
+        [Service]
+        ExecStart=/usr/bin/etcd
+storage:
+  files:
+    - path: /etc/motd
+      contents:
+        inline: hello
+EOT
+  ]
+}
+`
+
+func TestFedoraCoreOSIntrospection(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: fedoraCoreOSIntrospection,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.introspect", "files.#", "2"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "files.0.path", "/etc/hostname"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "files.0.mode", "420"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "files.0.owner", "core"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "files.0.group", "1000"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "files.1.path", "/etc/motd"),
+					r.TestCheckNoResourceAttr("data.ct_config.introspect", "files.1.mode"),
+					r.TestCheckNoResourceAttr("data.ct_config.introspect", "files.1.owner"),
+					// Butane adds a mount unit for the filesystem
+					r.TestCheckResourceAttr("data.ct_config.introspect", "systemd_units.#", "3"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "systemd_units.0.name", "var-data.mount"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "systemd_units.0.enabled", "true"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "systemd_units.1.name", "docker.service"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "systemd_units.2.name", "etcd.service"),
+					r.TestCheckNoResourceAttr("data.ct_config.introspect", "systemd_units.2.enabled"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "users.#", "1"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "users.0.name", "core"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "users.0.groups.#", "2"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "users.0.groups.1", "docker"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "filesystems.0.device", "/dev/mapper/data"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "filesystems.0.format", "xfs"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "filesystems.0.path", "/var/data"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "disks.0.device", "/dev/vdb"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "disks.0.partitions.#", "1"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "disks.0.partitions.0", "data"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "luks.0.name", "data"),
+					r.TestCheckResourceAttr("data.ct_config.introspect", "luks.0.device", "/dev/disk/by-partlabel/data"),
+				),
+			},
+		},
+	})
+}
+
+const fedoraCoreOSLint = `
+data "ct_config" "lint" {
+  lint = true
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+    - name: deploy
+      ssh_authorized_keys:
+        - ssh-ed25519 AAAA
+systemd:
+  units:
+    - name: hello.service
+      contents: |
+        [Service]
+        ExecStart=/usr/bin/echo hello
+    - name: etcd.service
+      contents: |
+        [Service]
+        ExecStart=/usr/bin/etcd
+      dropins:
+        - name: 10-install.conf
+          contents: |
+            [Install]
+            WantedBy=multi-user.target
+storage:
+  files:
+    - path: /etc/motd
+      contents:
+        inline: hello
+    - path: /etc/systemd/system/service.d/10-env.conf
+      contents:
+        inline: "[Service]"
+    - path: /etc/issue
+      contents:
+        inline: welcome
+EOT
+  snippets = [
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: hello.service
+      enabled: true
+    - name: etcd.service
+      enabled: true
+    - name: docker.service
+      dropins:
+        - name: 10-env.conf
+          contents: |
+            [Service]
+            Environment=DEBUG=1
+storage:
+  files:
+    - path: /etc/motd
+      contents:
+        inline: goodbye
+    - path: /etc/systemd/system/missing.service.d/10-env.conf
+      contents:
+        inline: "[Service]"
+    - path: /etc/issue
+      mode: 0600
+      append:
+        - inline: more
+EOT
+  ]
+}
+`
+
+const fedoraCoreOSLintStrict = `
+data "ct_config" "lint" {
+  lint = true
+  strict = true
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+EOT
+}
+`
+
+// dropins for units the OS provides are only warned about
+const fedoraCoreOSLintStrictDropin = `
+data "ct_config" "lint" {
+  lint = true
+  strict = true
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - ssh-ed25519 AAAA
+systemd:
+  units:
+    - name: docker.service
+      dropins:
+        - name: 10-env.conf
+          contents: |
+            [Service]
+            Environment=DEBUG=1
+EOT
+}
+`
+
+func TestFedoraCoreOSLint(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: fedoraCoreOSLint,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.lint", "warnings.#", "5"),
+					r.TestCheckResourceAttr("data.ct_config.lint", "warnings.0.message", "unit hello.service is enabled, but has no [Install] section"),
+					r.TestCheckResourceAttr("data.ct_config.lint", "warnings.0.path", "$.systemd.units.0"),
+					r.TestCheckResourceAttr("data.ct_config.lint", "warnings.0.source", "snippets[0]"),
+					r.TestCheckResourceAttr("data.ct_config.lint", "warnings.1.message", "dropins for unit docker.service, which the config doesn't define"),
+					r.TestCheckResourceAttr("data.ct_config.lint", "warnings.1.source", "snippets[0]"),
+					r.TestCheckResourceAttr("data.ct_config.lint", "warnings.2.message", "dropin /etc/systemd/system/missing.service.d/10-env.conf for unit missing.service, which the config doesn't define"),
+					r.TestCheckResourceAttr("data.ct_config.lint", "warnings.2.path", "$.storage.files.1"),
+					r.TestCheckResourceAttr("data.ct_config.lint", "warnings.3.message", "file /etc/motd from content is overwritten with different contents"),
+					r.TestCheckResourceAttr("data.ct_config.lint", "warnings.3.source", "snippets[0]"),
+					r.TestCheckResourceAttr("data.ct_config.lint", "warnings.4.message", "user core has no ssh_authorized_keys or password_hash"),
+					r.TestCheckResourceAttr("data.ct_config.lint", "warnings.4.path", "$.passwd.users.0"),
+				),
+			},
+			{
+				Config:      fedoraCoreOSLintStrict,
+				ExpectError: regexp.MustCompile(`strict lint error: user core has no\s+ssh_authorized_keys or password_hash`),
+			},
+			{
+				Config: fedoraCoreOSLintStrictDropin,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.lint", "warnings.#", "1"),
+					r.TestCheckResourceAttr("data.ct_config.lint", "warnings.0.message", "dropins for unit docker.service, which the config doesn't define"),
+				),
+			},
+		},
+	})
+}
+
+const fedoraCoreOSExplain = `
+data "ct_config" "explain" {
+  explain = true
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+systemd:
+  units:
+    - name: docker.service
+      enabled: true
+storage:
+  files:
+    - path: /etc/hostname
+      contents:
+        inline: node1
+EOT
+  snippets = [
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+storage:
+  files:
+    - path: /etc/hostname
+      contents:
+        inline: node2
+EOT
+  ]
+  named_snippets = {
+    units = <<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      enabled: false
+    - name: etcd.service
+      enabled: true
+EOT
+  }
+  ignition_snippets = [
+    jsonencode({
+      ignition = { version = "3.4.0" }
+      storage = { files = [{ path = "/etc/hostname", contents = { source = "data:,node3" } }] }
+    })
+  ]
+}
+`
+
+const fedoraCoreOSExplainReport = `file:/etc/hostname: ignition_snippets[0] (overrides content, snippets[0])
+unit:docker.service: named_snippets["units"] (overrides content)
+unit:etcd.service: named_snippets["units"]
+user:core: content
+`
+
+func TestFedoraCoreOSExplain(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: fedoraCoreOSExplain,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.explain", "explanation.%", "4"),
+					r.TestCheckResourceAttr("data.ct_config.explain", "explanation.file:/etc/hostname", "ignition_snippets[0]"),
+					r.TestCheckResourceAttr("data.ct_config.explain", "explanation.unit:docker.service", `named_snippets["units"]`),
+					r.TestCheckResourceAttr("data.ct_config.explain", "explanation.user:core", "content"),
+					r.TestCheckResourceAttr("data.ct_config.explain", "explanation_report", fedoraCoreOSExplainReport),
+				),
+			},
+			{
+				Config: fedoraCoreOSV15Resource,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckNoResourceAttr("data.ct_config.fedora-coreos", "explanation.%"),
+					r.TestCheckNoResourceAttr("data.ct_config.fedora-coreos", "explanation_report"),
+				),
+			},
+		},
+	})
+}
+
+const fedoraCoreOSConflict = `
+data "ct_config" "conflict" {
+  conflict_policy = "%s"
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      enabled: true
+storage:
+  files:
+    - path: /etc/hostname
+      mode: 0644
+      contents:
+        inline: node1
+    - path: /etc/motd
+      contents:
+        inline: hello
+EOT
+  snippets = [
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      dropins:
+        - name: 10-env.conf
+          contents: |
+            [Service]
+            Environment=DEBUG=1
+storage:
+  files:
+    - path: /etc/hostname
+      mode: 0600
+      contents:
+        inline: node2
+    - path: /etc/motd
+      contents:
+        inline: hello
+EOT
+  ]
+}
+`
+
+func TestFedoraCoreOSConflictPolicy(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config:      fmt.Sprintf(fedoraCoreOSConflict, "deny"),
+				ExpectError: regexp.MustCompile(`value must be one of`),
+			},
+			{
+				Config: fmt.Sprintf(fedoraCoreOSConflict, "allow"),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.conflict", "warnings.#", "0"),
+				),
+			},
+			{
+				// units merge with dropins and identical files don't conflict
+				Config: fmt.Sprintf(fedoraCoreOSConflict, "warn"),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.conflict", "warnings.#", "1"),
+					r.TestCheckResourceAttr("data.ct_config.conflict", "warnings.0.message", "file:/etc/hostname from content is overridden by snippets[0] with different contents.source, mode"),
+					r.TestCheckResourceAttr("data.ct_config.conflict", "warnings.0.path", "$.storage.files.0"),
+					r.TestCheckResourceAttr("data.ct_config.conflict", "warnings.0.source", "snippets[0]"),
+				),
+			},
+			{
+				Config:      fmt.Sprintf(fedoraCoreOSConflict, "error"),
+				ExpectError: regexp.MustCompile(`conflict: file:/etc/hostname from content is overridden by snippets\[0\](.|\n)*content at \$.storage.files.0, snippets\[0\] at \$.storage.files.0`),
+			},
+		},
+	})
+}
+
+const fedoraCoreOSSnippetFilesDir = `
+data "ct_config" "modules" {
+  files_dir = %q
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+storage:
+  files:
+    - path: /etc/hostname
+      contents:
+        local: hostname
+EOT
+  snippets = [
+    <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+EOT
+  ]
+
+  snippet {
+    files_dir = %q
+    content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+storage:
+  files:
+    - path: /etc/motd
+      contents:
+        local: motd
+EOT
+  }
+}
+`
+
+const fedoraCoreOSSnippetInvalid = `
+data "ct_config" "modules" {
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+  snippet {
+    files_dir = "."
+  }
+}
+`
+
+const fedoraCoreOSSnippetMissingFile = `
+data "ct_config" "modules" {
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+  snippet {
+    files_dir = %q
+    content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+storage:
+  files:
+    - path: /etc/issue
+      contents:
+        local: missing
+EOT
+  }
+}
+`
+
+func TestFedoraCoreOSSnippetFilesDir(t *testing.T) {
+	rootDir, moduleDir := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(rootDir, "hostname"), []byte("node1"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(moduleDir, "motd"), []byte("from-module"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config:      fedoraCoreOSSnippetInvalid,
+				ExpectError: regexp.MustCompile(`The argument "content" is required`),
+			},
+			{
+				Config:      fmt.Sprintf(fedoraCoreOSSnippetMissingFile, moduleDir),
+				ExpectError: regexp.MustCompile(`\d+:\s+content = <<EOT(.|\n)*snippet\[0\]: error at \$\.storage\.files\.0\.contents\.local`),
+			},
+			{
+				Config: fmt.Sprintf(fedoraCoreOSSnippetFilesDir, rootDir, moduleDir),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.modules", "users.0.name", "core"),
+					r.TestMatchResourceAttr("data.ct_config.modules", "rendered", regexp.MustCompile(`"path":"/etc/hostname"(.*)"source":"data:,node1"`)),
+					r.TestMatchResourceAttr("data.ct_config.modules", "rendered", regexp.MustCompile(`"path":"/etc/motd"(.*)"source":"data:,from-module"`)),
+				),
+			},
+		},
+	})
+}
+
+const fedoraCoreOSTrees = `
+data "ct_config" "trees" {
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+  snippets = [
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+storage:
+  files:
+    - path: /opt/app/etc/app.conf
+      contents:
+        inline: override
+EOT
+  ]
+  explain = true
+
+  trees {
+    local   = %q
+    path    = "/opt/app"
+    exclude = [".git", "*.swp"]
+    modes = {
+      "*.key" = "0600"
+    }
+    max_file_size = %d
+  }
+}
+`
+
+// ** matches any number of directories
+const fedoraCoreOSTreesGlobstar = `
+data "ct_config" "trees" {
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+
+  trees {
+    local   = %q
+    path    = "/opt/app"
+    include = ["etc/**", "tls/**/*.key"]
+    exclude = ["**/old/**"]
+    modes = {
+      "tls/**" = "0600"
+    }
+  }
+}
+`
+
+func TestFedoraCoreOSTrees(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]struct {
+		content string
+		mode    os.FileMode
+	}{
+		"etc/app.conf":       {"setting = 1", 0644},
+		"etc/app.conf.swp":   {"swap", 0644},
+		"bin/run":            {"#!/bin/sh", 0755},
+		"tls/server.key":     {"secret", 0644},
+		".git/HEAD":          {"ref: refs/heads/main", 0644},
+		".git/objects/large": {strings.Repeat("x", 64), 0644},
+	}
+	for name, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if err := os.WriteFile(p, []byte(f.content), f.mode); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	globstarDir := t.TempDir()
+	for _, name := range []string{"etc/app.conf", "etc/conf.d/10-app.conf", "etc/conf.d/old/09-app.conf", "tls/server.key", "tls/certs/ca/ca.key", "tls/certs/ca/ca.crt", "bin/run"} {
+		p := filepath.Join(globstarDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if err := os.WriteFile(p, []byte(name), 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	symlinkDir := t.TempDir()
+	if err := os.Symlink("/etc/hostname", filepath.Join(symlinkDir, "hostname")); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config:      fmt.Sprintf(fedoraCoreOSTrees, symlinkDir, 1024),
+				ExpectError: regexp.MustCompile(`hostname is a symlink,\s+which trees don't support`),
+			},
+			{
+				Config:      fmt.Sprintf(fedoraCoreOSTrees, dir, 8),
+				ExpectError: regexp.MustCompile(`bin/run is 9 bytes,\s+larger\s+than the max_file_size of 8 bytes`),
+			},
+			{
+				Config: fmt.Sprintf(fedoraCoreOSTrees, dir, 1024),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.#", "3"),
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.0.path", "/opt/app/bin/run"),
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.0.mode", "493"),
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.1.path", "/opt/app/etc/app.conf"),
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.1.mode", "420"),
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.2.path", "/opt/app/tls/server.key"),
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.2.mode", "384"),
+					// snippets are merged after trees
+					r.TestCheckResourceAttr("data.ct_config.trees", "explanation.file:/opt/app/etc/app.conf", "snippets[0]"),
+					r.TestCheckResourceAttr("data.ct_config.trees", "explanation.file:/opt/app/bin/run", "trees[0]"),
+					r.TestMatchResourceAttr("data.ct_config.trees", "rendered", regexp.MustCompile(`"source":"data:,secret"`)),
+				),
+			},
+			{
+				Config: fmt.Sprintf(fedoraCoreOSTreesGlobstar, globstarDir),
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.#", "4"),
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.0.path", "/opt/app/etc/app.conf"),
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.0.mode", "420"),
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.1.path", "/opt/app/etc/conf.d/10-app.conf"),
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.2.path", "/opt/app/tls/certs/ca/ca.key"),
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.2.mode", "384"),
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.3.path", "/opt/app/tls/server.key"),
+					r.TestCheckResourceAttr("data.ct_config.trees", "files.3.mode", "384"),
+				),
+			},
+		},
+	})
+}
@@ -87,7 +87,7 @@ EOT
 
 func TestButaneConfig_Flatcar_v1_1(t *testing.T) {
 	r.UnitTest(t, r.TestCase{
-		Providers: testProviders,
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
 		Steps: []r.TestStep{
 			{
 				Config: flatcarV11Resource,
@@ -272,7 +272,7 @@ const flatcarWithSnippetsPrettyFalseExpected = `{"ignition":{"config":{"replace"
 
 func TestButaneConfig_Flatcar_v1_0(t *testing.T) {
 	r.UnitTest(t, r.TestCase{
-		Providers: testProviders,
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
 		Steps: []r.TestStep{
 			{
 				Config: flatcarV10Resource,
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	sdkdiag "github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func DatasourceIgnitionMerge() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceIgnitionMergeRead,
+
+		Schema: map[string]*schema.Schema{
+			"configs": {
+				Type: schema.TypeList,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Required:    true,
+				MinItems:    1,
+				Description: "Ignition JSON configs to merge, in order",
+			},
+			"ignition_version": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      defaultIgnitionVersion,
+				ValidateFunc: validation.StringInSlice(ignitionVersions(), false),
+				Description:  "Ignition spec version of the rendered configuration",
+			},
+			"pretty_print": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"rendered": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "merged ignition configuration",
+			},
+		},
+	}
+}
+
+func datasourceIgnitionMergeRead(ctx context.Context, d *schema.ResourceData, meta interface{}) sdkdiag.Diagnostics {
+	configs := stringList(d.Get("configs").([]interface{}))
+	pretty := d.Get("pretty_print").(bool)
+	spec := ignitionSpecs[d.Get("ignition_version").(string)]
+
+	rendered, mergeDiags := mergeIgnition(configs, pretty, spec)
+	diags := sdkDiagnostics(mergeDiags)
+	if diags.HasError() {
+		return diags
+	}
+
+	if err := d.Set("rendered", string(rendered)); err != nil {
+		return sdkdiag.FromErr(err)
+	}
+	d.SetId(hashcode(string(rendered)))
+	return diags
+}
+
+// Parse Ignition configs and merge them in order into an Ignition Config of
+// the given spec version.
+func mergeIgnition(configs []string, pretty bool, spec ignitionSpec) ([]byte, diag.Diagnostics) {
+	var ign interface{}
+	var diags diag.Diagnostics
+	for i, config := range configs {
+		in := listInput("configs", i)
+		ignext, report, err := spec.Parse([]byte(config))
+		diags = append(diags, reportDiagnostics(report, in, false)...)
+		if err != nil {
+			return nil, append(diags, in.errorDiagnostic("Ignition parse error", err))
+		}
+		if ign == nil {
+			ign = ignext
+			continue
+		}
+		ign = spec.Merge(ign, ignext)
+	}
+
+	ignBytes, err := marshalJSON(ign, pretty)
+	if err != nil {
+		diags.AddError("Ignition marshal error", err.Error())
+		return nil, diags
+	}
+	return ignBytes, diags
+}
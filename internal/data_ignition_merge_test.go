@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"regexp"
+	"testing"
+
+	r "github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+const ignitionMerge = `
+data "ct_ignition_merge" "merge" {
+  configs = [
+<<EOT
+{"ignition":{"version":"3.3.0"},"passwd":{"users":[{"name":"core","sshAuthorizedKeys":["key"]}]}}
+EOT
+,
+<<EOT
+{"ignition":{"version":"3.4.0"},"systemd":{"units":[{"name":"docker.service","enabled":true}]}}
+EOT
+  ]
+}
+`
+
+const ignitionMergeInvalid = `
+data "ct_ignition_merge" "merge" {
+  configs = [
+<<EOT
+{"ignition":{"version":"3.4.0"}}
+EOT
+,
+<<EOT
+{"ignition":{"version":"3.4.0"},
+EOT
+  ]
+}
+`
+
+func TestIgnitionMerge(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: ignitionMerge,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_ignition_merge.merge", "rendered", ignitionV34WithSnippetsPrettyFalseExpected),
+				),
+			},
+			{
+				Config:      ignitionMergeInvalid,
+				ExpectError: regexp.MustCompile(`unexpected end of JSON input(.|\n)*configs\[1\]`),
+			},
+		},
+	})
+}
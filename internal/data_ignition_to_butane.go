@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ignitionToButaneDataSource translates Ignition configs to Butane configs,
+// to migrate hand-written Ignition to ct_config.
+type ignitionToButaneDataSource struct{}
+
+var _ datasource.DataSource = &ignitionToButaneDataSource{}
+
+func NewIgnitionToButaneDataSource() datasource.DataSource {
+	return &ignitionToButaneDataSource{}
+}
+
+// ignitionToButaneModel is the ct_ignition_to_butane configuration and state.
+type ignitionToButaneModel struct {
+	ID      types.String `tfsdk:"id"`
+	Content types.String `tfsdk:"content"`
+	Variant types.String `tfsdk:"variant"`
+	Version types.String `tfsdk:"version"`
+	Butane  types.String `tfsdk:"butane"`
+}
+
+func (d *ignitionToButaneDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_ignition_to_butane"
+}
+
+func (d *ignitionToButaneDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Translate an Ignition config to an equivalent Butane config",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+			},
+			"content": schema.StringAttribute{
+				Required:    true,
+				Description: "Ignition JSON config to translate",
+			},
+			"variant": schema.StringAttribute{
+				Required:    true,
+				Description: "Butane variant of the translated config (e.g. fcos, flatcar)",
+			},
+			"version": schema.StringAttribute{
+				Required:    true,
+				Description: "Butane version of the translated config (e.g. 1.5.0)",
+			},
+			"butane": schema.StringAttribute{
+				Computed:    true,
+				Description: "translated Butane YAML config",
+			},
+		},
+	}
+}
+
+func (d *ignitionToButaneDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var cfg ignitionToButaneModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &cfg)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	out, diags := ignitionToButane([]byte(cfg.Content.ValueString()), cfg.Variant.ValueString(), cfg.Version.ValueString())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	cfg.Butane = types.StringValue(string(out))
+	cfg.ID = types.StringValue(hashcode(string(out)))
+	resp.Diagnostics.Append(resp.State.Set(ctx, &cfg)...)
+}
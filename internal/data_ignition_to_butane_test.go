@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"regexp"
+	"testing"
+
+	r "github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+const ignitionToButaneConfig = `
+data "ct_ignition_to_butane" "files" {
+  variant = "fcos"
+  version = "1.5.0"
+  content = jsonencode({
+    ignition = { version = "3.3.0" }
+    storage = {
+      files = [
+        {
+          path     = "/etc/hostname"
+          mode     = 420
+          contents = { source = "data:,node1%0A" }
+        },
+        {
+          path     = "/etc/motd"
+          contents = { compression = "gzip", source = "data:;base64,H4sIAPBB1GoC/8tIzcnJVyjPL8pJ4QIALTsIrwwAAAA=" }
+        },
+        {
+          path     = "/etc/issue"
+          contents = { compression = "", source = "data:,welcome" }
+        },
+        {
+          path     = "/opt/bin/tool"
+          mode     = 493
+          contents = { source = "https://example.com/tool" }
+        },
+      ]
+    }
+  })
+}
+`
+
+const ignitionToButaneExpected = `---
+variant: fcos
+version: 1.5.0
+storage:
+  files:
+    - path: /etc/hostname
+      contents:
+        inline: |
+          node1
+      mode: 0644
+    - path: /etc/motd
+      contents:
+        inline: |
+          hello world
+    - path: /etc/issue
+      contents:
+        inline: welcome
+    - path: /opt/bin/tool
+      contents:
+        source: https://example.com/tool
+      mode: 0755
+`
+
+// translated Butane renders the original Ignition
+const ignitionToButaneRoundTrip = `
+data "ct_ignition_to_butane" "roundtrip" {
+  variant = "fcos"
+  version = "1.5.0"
+  content = <<EOT
+` + ignitionV34WithSnippetsPrettyFalseExpected + `
+EOT
+}
+
+data "ct_config" "roundtrip" {
+  content = data.ct_ignition_to_butane.roundtrip.butane
+  strict  = true
+}
+`
+
+const ignitionToButaneUnsupported = `
+data "ct_ignition_to_butane" "unsupported" {
+  variant = "fcos"
+  version = "1.4.0"
+  content = jsonencode({
+    ignition = { version = "3.4.0" }
+    storage = { luks = [{ name = "data", device = "/dev/vdb", discard = true }] }
+  })
+}
+`
+
+func TestIgnitionToButane(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: ignitionToButaneConfig,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_ignition_to_butane.files", "butane", ignitionToButaneExpected),
+				),
+			},
+			{
+				Config: ignitionToButaneRoundTrip,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.roundtrip", "rendered", ignitionV34WithSnippetsPrettyFalseExpected),
+				),
+			},
+			{
+				Config:      ignitionToButaneUnsupported,
+				ExpectError: regexp.MustCompile(`unavailable in Ignition 3.3.0:\s+storage.luks\[0\].discard`),
+			},
+		},
+	})
+}
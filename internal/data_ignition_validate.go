@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/coreos/ignition/v2/config/shared/errors"
+	"github.com/coreos/ignition/v2/config/util"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ignitionValidateDataSource validates Ignition configs produced elsewhere
+// (e.g. by other modules), reporting problems without failing unless strict.
+type ignitionValidateDataSource struct{}
+
+var _ datasource.DataSource = &ignitionValidateDataSource{}
+
+func NewIgnitionValidateDataSource() datasource.DataSource {
+	return &ignitionValidateDataSource{}
+}
+
+// ignitionValidateModel is the ct_ignition_validate configuration and state.
+type ignitionValidateModel struct {
+	ID       types.String `tfsdk:"id"`
+	Content  types.String `tfsdk:"content"`
+	Strict   types.Bool   `tfsdk:"strict"`
+	Valid    types.Bool   `tfsdk:"valid"`
+	Version  types.String `tfsdk:"version"`
+	Errors   types.List   `tfsdk:"errors"`
+	Warnings types.List   `tfsdk:"warnings"`
+}
+
+func (d *ignitionValidateDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_ignition_validate"
+}
+
+func (d *ignitionValidateDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Validate an Ignition config",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+			},
+			"content": schema.StringAttribute{
+				Required:    true,
+				Description: "Ignition JSON config to validate",
+			},
+			"strict": schema.BoolAttribute{
+				Optional:    true,
+				Description: "fail on validation errors and warnings, instead of only reporting them",
+			},
+			"valid": schema.BoolAttribute{
+				Computed:    true,
+				Description: "whether the config has no validation errors",
+			},
+			"version": schema.StringAttribute{
+				Computed:    true,
+				Description: "Ignition spec version of the config",
+			},
+			"errors": schema.ListAttribute{
+				ElementType: warningType,
+				Computed:    true,
+				Description: "validation errors",
+			},
+			"warnings": schema.ListAttribute{
+				ElementType: warningType,
+				Computed:    true,
+				Description: "validation warnings",
+			},
+		},
+	}
+}
+
+func (d *ignitionValidateDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var cfg ignitionValidateModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &cfg)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	content := []byte(cfg.Content.ValueString())
+
+	// parse as the latest spec, which accepts configs of any supported version
+	versions := ignitionVersions()
+	spec := ignitionSpecs[versions[len(versions)-1]]
+	_, report, err := spec.Parse(content)
+	warnings := reportWarnings(report, contentInput)
+	errs := reportErrors(report, contentInput)
+	// invalid configs are described by their report entries
+	if err != nil && err != errors.ErrInvalid {
+		errs = append(errs, warning{message: err.Error(), source: contentInput.name})
+	}
+
+	if cfg.Strict.ValueBool() {
+		resp.Diagnostics.Append(reportDiagnostics(report, contentInput, true)...)
+		if err != nil && err != errors.ErrInvalid {
+			resp.Diagnostics.Append(contentInput.errorDiagnostic("Ignition parse error", err))
+		}
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	cfg.Version = types.StringNull()
+	if version, _, err := util.GetConfigVersion(content); err == nil {
+		cfg.Version = types.StringValue(version.String())
+	}
+	cfg.Valid = types.BoolValue(len(errs) == 0)
+	cfg.Errors = flattenWarnings(errs)
+	cfg.Warnings = flattenWarnings(warnings)
+	cfg.ID = types.StringValue(hashcode(cfg.Content.ValueString()))
+	resp.Diagnostics.Append(resp.State.Set(ctx, &cfg)...)
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"regexp"
+	"testing"
+
+	r "github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+const ignitionValidateConfig = `
+data "ct_ignition_validate" "valid" {
+  content = jsonencode({
+    ignition = { version = "3.3.0" }
+    passwd   = { users = [{ name = "core", sshAuthorizedKeys = ["key"] }] }
+  })
+}
+
+data "ct_ignition_validate" "invalid" {
+  content = jsonencode({
+    ignition = { version = "3.4.0" }
+    storage  = { files = [{ path = "etc/hostname" }] }
+    unknown  = true
+  })
+}
+
+data "ct_ignition_validate" "malformed" {
+  content = "{"
+}
+`
+
+const ignitionValidateStrict = `
+data "ct_ignition_validate" "invalid" {
+  strict  = true
+  content = jsonencode({
+    ignition = { version = "3.4.0" }
+    storage  = { files = [{ path = "etc/hostname" }] }
+  })
+}
+`
+
+func TestIgnitionValidate(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: ignitionValidateConfig,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_ignition_validate.valid", "valid", "true"),
+					r.TestCheckResourceAttr("data.ct_ignition_validate.valid", "version", "3.3.0"),
+					r.TestCheckResourceAttr("data.ct_ignition_validate.valid", "errors.#", "0"),
+					r.TestCheckResourceAttr("data.ct_ignition_validate.valid", "warnings.#", "0"),
+					r.TestCheckResourceAttr("data.ct_ignition_validate.invalid", "valid", "false"),
+					r.TestCheckResourceAttr("data.ct_ignition_validate.invalid", "version", "3.4.0"),
+					r.TestCheckResourceAttr("data.ct_ignition_validate.invalid", "errors.#", "1"),
+					r.TestCheckResourceAttr("data.ct_ignition_validate.invalid", "errors.0.message", "path not absolute"),
+					r.TestCheckResourceAttr("data.ct_ignition_validate.invalid", "errors.0.path", "$.storage.files.0.path"),
+					r.TestCheckResourceAttr("data.ct_ignition_validate.invalid", "warnings.#", "1"),
+					r.TestCheckResourceAttr("data.ct_ignition_validate.invalid", "warnings.0.message", "unused key unknown"),
+					r.TestCheckResourceAttr("data.ct_ignition_validate.malformed", "valid", "false"),
+					r.TestCheckNoResourceAttr("data.ct_ignition_validate.malformed", "version"),
+					r.TestCheckResourceAttr("data.ct_ignition_validate.malformed", "errors.#", "1"),
+				),
+			},
+			{
+				Config:      ignitionValidateStrict,
+				ExpectError: regexp.MustCompile(`path not absolute`),
+			},
+		},
+	})
+}
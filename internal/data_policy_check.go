@@ -0,0 +1,208 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// policyCheckDataSource checks rendered Ignition configs against policy
+// rules, reporting a diagnostic per violation.
+type policyCheckDataSource struct{}
+
+var _ datasource.DataSource = &policyCheckDataSource{}
+
+func NewPolicyCheckDataSource() datasource.DataSource {
+	return &policyCheckDataSource{}
+}
+
+// policyCheckModel is the ct_policy_check configuration and state.
+type policyCheckModel struct {
+	ID         types.String `tfsdk:"id"`
+	Content    types.String `tfsdk:"content"`
+	Rules      []ruleModel  `tfsdk:"rule"`
+	Violations types.List   `tfsdk:"violations"`
+}
+
+type ruleModel struct {
+	Name     types.String `tfsdk:"name"`
+	Select   types.String `tfsdk:"select"`
+	Absent   types.Bool   `tfsdk:"absent"`
+	Present  types.Bool   `tfsdk:"present"`
+	Equals   types.String `tfsdk:"equals"`
+	OneOf    types.List   `tfsdk:"one_of"`
+	Matches  types.String `tfsdk:"matches"`
+	Message  types.String `tfsdk:"message"`
+	Severity types.String `tfsdk:"severity"`
+}
+
+// violationType is the element type of the violations attribute.
+var violationType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"rule":    types.StringType,
+		"path":    types.StringType,
+		"message": types.StringType,
+	},
+}
+
+func (d *policyCheckDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_policy_check"
+}
+
+func (d *policyCheckDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Check an Ignition config against policy rules",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+			},
+			"content": schema.StringAttribute{
+				Required:    true,
+				Description: "Ignition JSON config to check (e.g. a ct_config rendered)",
+			},
+			"violations": schema.ListAttribute{
+				ElementType: violationType,
+				Computed:    true,
+				Description: "rule violations, including those with warning severity",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"rule": schema.ListNestedBlock{
+				Description: "policy rule that selected values must satisfy",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Required:    true,
+							Description: "rule name, shown in violations",
+						},
+						"select": schema.StringAttribute{
+							Required:    true,
+							Description: "selector of values, using Butane field names (e.g. storage.files[path=/etc/ssh/*].mode)",
+						},
+						"absent": schema.BoolAttribute{
+							Optional:    true,
+							Description: "selected values must be absent",
+						},
+						"present": schema.BoolAttribute{
+							Optional:    true,
+							Description: "selected values must be present, and something must be selected",
+						},
+						"equals": schema.StringAttribute{
+							Optional:    true,
+							Description: "selected values must equal this value (numbers may be octal, e.g. 0600)",
+						},
+						"one_of": schema.ListAttribute{
+							ElementType: types.StringType,
+							Optional:    true,
+							Description: "selected values must be one of these values",
+						},
+						"matches": schema.StringAttribute{
+							Optional:    true,
+							Description: "selected values must match this regular expression",
+						},
+						"message": schema.StringAttribute{
+							Optional:    true,
+							Description: "message describing violations",
+						},
+						"severity": schema.StringAttribute{
+							Optional:    true,
+							Description: "severity of violation diagnostics, error (default) or warning",
+							Validators: []validator.String{
+								stringvalidator.OneOf("error", "warning"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *policyCheckDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var cfg policyCheckModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &cfg)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	rules, diags := policyRules(cfg.Rules)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var config interface{}
+	if err := json.Unmarshal([]byte(cfg.Content.ValueString()), &config); err != nil {
+		resp.Diagnostics.Append(contentInput.errorDiagnostic("Ignition parse error", err))
+		return
+	}
+
+	violations := evaluate(butaneKeys(config), rules)
+	elems := make([]attr.Value, len(violations))
+	for i, v := range violations {
+		elems[i] = types.ObjectValueMust(violationType.AttrTypes, map[string]attr.Value{
+			"rule":    types.StringValue(v.rule),
+			"path":    types.StringValue(v.path),
+			"message": types.StringValue(v.message),
+		})
+
+		summary := fmt.Sprintf("policy violation: %s", v.rule)
+		detail := fmt.Sprintf("%s: %s", v.path, v.message)
+		if v.severity == "warning" {
+			resp.Diagnostics.AddAttributeWarning(contentInput.path, summary, detail)
+		} else {
+			resp.Diagnostics.AddAttributeError(contentInput.path, summary, detail)
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	cfg.Violations = types.ListValueMust(violationType, elems)
+	cfg.ID = types.StringValue(hashcode(cfg.Content.ValueString()))
+	resp.Diagnostics.Append(resp.State.Set(ctx, &cfg)...)
+}
+
+// policyRules converts and validates rule blocks.
+func policyRules(models []ruleModel) ([]policyRule, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	var rules []policyRule
+	for i, m := range models {
+		at := path.Root("rule").AtListIndex(i)
+		rule := policyRule{
+			name:     m.Name.ValueString(),
+			absent:   m.Absent.ValueBool(),
+			present:  m.Present.ValueBool(),
+			equals:   m.Equals.ValueStringPointer(),
+			oneOf:    listStrings(m.OneOf),
+			message:  m.Message.ValueString(),
+			severity: m.Severity.ValueString(),
+		}
+		selector, err := parseSelector(m.Select.ValueString())
+		if err != nil {
+			diags.AddAttributeError(at.AtName("select"), "invalid rule selector", err.Error())
+		}
+		rule.selector = selector
+		if !m.Matches.IsNull() {
+			rule.matches, err = regexp.Compile(m.Matches.ValueString())
+			if err != nil {
+				diags.AddAttributeError(at.AtName("matches"), "invalid rule regular expression", err.Error())
+			}
+		}
+		if !rule.absent && !rule.present && rule.equals == nil && len(rule.oneOf) == 0 && m.Matches.IsNull() {
+			diags.AddAttributeError(at, "rule requires a predicate", fmt.Sprintf("rule %q must set absent, present, equals, one_of, or matches", rule.name))
+		}
+		rules = append(rules, rule)
+	}
+	return rules, diags
+}
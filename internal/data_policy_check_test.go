@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"regexp"
+	"testing"
+
+	r "github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+const policyCheckContent = `
+data "ct_config" "policy" {
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - ssh-ed25519 AAAA-allowed
+        - ssh-ed25519 AAAA-other
+storage:
+  files:
+    - path: /etc/ssh/sshd_config.d/10-custom.conf
+      mode: 0600
+      contents:
+        inline: PasswordAuthentication no
+    - path: /etc/ssh/ssh_known_hosts
+      mode: 0644
+      contents:
+        inline: host key
+    - path: /etc/hostname
+      contents:
+        inline: node1
+EOT
+}
+`
+
+const policyCheckPass = policyCheckContent + `
+data "ct_policy_check" "pass" {
+  content = data.ct_config.policy.rendered
+
+  rule {
+    name   = "no-password-hashes"
+    select = "passwd.users[*].password_hash"
+    absent = true
+  }
+  rule {
+    name   = "sshd-config-mode"
+    select = "storage.files[path=/etc/ssh/sshd_config.d/*].mode"
+    equals = "0600"
+  }
+  rule {
+    name    = "core-user"
+    select  = "passwd.users[name=core]"
+    present = true
+  }
+}
+`
+
+const policyCheckWarnings = policyCheckContent + `
+data "ct_policy_check" "warnings" {
+  content = data.ct_config.policy.rendered
+
+  rule {
+    name     = "ssh-mode"
+    select   = "storage.files[path=/etc/ssh/*].mode"
+    equals   = "0600"
+    severity = "warning"
+  }
+  rule {
+    name     = "ssh-key-allowlist"
+    select   = "passwd.users[*].ssh_authorized_keys[*]"
+    one_of   = ["ssh-ed25519 AAAA-allowed"]
+    message  = "ssh keys must be allowlisted"
+    severity = "warning"
+  }
+  rule {
+    name     = "file-modes"
+    select   = "storage.files[*].mode"
+    present  = true
+    severity = "warning"
+  }
+  rule {
+    name     = "ssh-files-mode"
+    select   = "storage.files[path=/etc/ssh/**].mode"
+    equals   = "0644"
+    severity = "warning"
+  }
+  rule {
+    name     = "no-ssh-keys"
+    select   = "passwd.users.ssh_authorized_keys"
+    absent   = true
+    severity = "warning"
+  }
+}
+`
+
+const policyCheckViolation = policyCheckContent + `
+data "ct_policy_check" "violation" {
+  content = data.ct_config.policy.rendered
+
+  rule {
+    name   = "ssh-mode"
+    select = "storage.files[path=/etc/ssh/*].mode"
+    equals = "0600"
+  }
+}
+`
+
+const policyCheckInvalid = policyCheckContent + `
+data "ct_policy_check" "invalid" {
+  content = data.ct_config.policy.rendered
+
+  rule {
+    name   = "invalid"
+    select = "storage.files[path=/etc/ssh/*.mode"
+    absent = true
+  }
+}
+`
+
+func TestPolicyCheck(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: policyCheckPass,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_policy_check.pass", "violations.#", "0"),
+				),
+			},
+			{
+				Config: policyCheckWarnings,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.#", "5"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.0.rule", "ssh-mode"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.0.path", "$.storage.files.1.mode"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.0.message", "value 0644 doesn't equal 0600"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.1.rule", "ssh-key-allowlist"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.1.path", "$.passwd.users.0.ssh_authorized_keys.1"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.1.message", "ssh keys must be allowlisted (value ssh-ed25519 AAAA-other isn't one of the allowed values)"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.2.rule", "file-modes"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.2.path", "$.storage.files.2.mode"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.2.message", "value is missing"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.3.rule", "ssh-files-mode"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.3.path", "$.storage.files.0.mode"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.3.message", "value 0600 doesn't equal 0644"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.4.rule", "no-ssh-keys"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.4.path", "$.passwd.users.0.ssh_authorized_keys"),
+					r.TestCheckResourceAttr("data.ct_policy_check.warnings", "violations.4.message", "value is present"),
+				),
+			},
+			{
+				Config:      policyCheckViolation,
+				ExpectError: regexp.MustCompile(`policy violation: ssh-mode(.|\n)*\$.storage.files.1.mode: value 0644 doesn't equal 0600`),
+			},
+			{
+				Config:      policyCheckInvalid,
+				ExpectError: regexp.MustCompile(`invalid rule selector`),
+			},
+		},
+	})
+}
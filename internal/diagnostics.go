@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/coreos/vcontext/report"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	sdkdiag "github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// input identifies the ct_config input (content or a snippet) a diagnostic
+// originates from.
+type input struct {
+	// name describes the input in messages (e.g. snippets[1])
+	name string
+	// path is the attribute path of the input
+	path path.Path
+}
+
+var contentInput = input{
+	name: "content",
+	path: path.Root("content"),
+}
+
+func snippetInput(i int) input {
+	return listInput("snippets", i)
+}
+
+// snippetBlockInput is a snippet block, whose diagnostics point at its
+// content.
+func snippetBlockInput(i int) input {
+	return input{
+		name: fmt.Sprintf("snippet[%d]", i),
+		path: path.Root("snippet").AtListIndex(i).AtName("content"),
+	}
+}
+
+func namedSnippetInput(name string) input {
+	return input{
+		name: fmt.Sprintf("named_snippets[%q]", name),
+		path: path.Root("named_snippets").AtMapKey(name),
+	}
+}
+
+// listInput returns the input for an element of a list attribute.
+func listInput(attr string, i int) input {
+	return input{
+		name: fmt.Sprintf("%s[%d]", attr, i),
+		path: path.Root(attr).AtListIndex(i),
+	}
+}
+
+// warning is a non-fatal report entry from translating an input.
+type warning struct {
+	message string
+	// path is the YAML path of the entry (e.g. $.passwd.users.0)
+	path string
+	// source is the input name (e.g. content, snippets[1])
+	source string
+}
+
+// reportWarnings returns the non-fatal entries of a report as warnings
+// attributed to an input.
+func reportWarnings(rpt report.Report, in input) []warning {
+	var warnings []warning
+	for _, entry := range rpt.Entries {
+		if entry.Kind.IsFatal() {
+			continue
+		}
+		warnings = append(warnings, warning{
+			message: entry.Message,
+			path:    entry.Context.String(),
+			source:  in.name,
+		})
+	}
+	return warnings
+}
+
+// reportErrors returns the error entries of a report, in the form of
+// warnings, attributed to an input.
+func reportErrors(rpt report.Report, in input) []warning {
+	var errs []warning
+	for _, entry := range rpt.Entries {
+		if !entry.Kind.IsFatal() {
+			continue
+		}
+		errs = append(errs, warning{
+			message: entry.Message,
+			path:    entry.Context.String(),
+			source:  in.name,
+		})
+	}
+	return errs
+}
+
+// warningType is the element type of the warnings attribute.
+var warningType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"message": types.StringType,
+		"path":    types.StringType,
+		"source":  types.StringType,
+	},
+}
+
+// flattenWarnings converts warnings to a value of the warnings attribute.
+func flattenWarnings(warnings []warning) types.List {
+	elems := make([]attr.Value, len(warnings))
+	for i, w := range warnings {
+		elems[i] = types.ObjectValueMust(warningType.AttrTypes, map[string]attr.Value{
+			"message": types.StringValue(w.message),
+			"path":    types.StringValue(w.path),
+			"source":  types.StringValue(w.source),
+		})
+	}
+	return types.ListValueMust(warningType, elems)
+}
+
+// errorDiagnostic returns an error diagnostic attributed to an input.
+func (in input) errorDiagnostic(summary string, err error) diag.Diagnostic {
+	return diag.NewAttributeErrorDiagnostic(in.path, summary, fmt.Sprintf("%s: %v", in.name, err))
+}
+
+// reportDiagnostics converts each entry of a Butane or Ignition report into a
+// diagnostic attributed to an input. Error entries are errors, other entries
+// are warnings unless strict is set.
+func reportDiagnostics(rpt report.Report, in input, strict bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, entry := range rpt.Entries {
+		switch {
+		case entry.Kind.IsFatal():
+			diags.AddAttributeError(in.path, entry.Message, entryLocation(entry, in))
+		case strict:
+			diags.AddAttributeError(in.path, fmt.Sprintf("strict parsing error: %s", entry.Message), entryLocation(entry, in))
+		default:
+			diags.AddAttributeWarning(in.path, entry.Message, entryLocation(entry, in))
+		}
+	}
+	return diags
+}
+
+// entryLocation describes where a report entry occurred, including the YAML
+// path, line, and column when known.
+func entryLocation(entry report.Entry, in input) string {
+	location := fmt.Sprintf("%s: %s", in.name, entry.Kind.String())
+	if entry.Context.Len() != 0 {
+		location = fmt.Sprintf("%s at %s", location, entry.Context.String())
+	}
+	if entry.Marker.StartP != nil && entry.Marker.StartP.Line != 0 {
+		location = fmt.Sprintf("%s, %s", location, entry.Marker.String())
+	}
+	return location
+}
+
+// sdkDiagnostics converts diagnostics for data sources served by the SDKv2
+// Provider.
+func sdkDiagnostics(diags diag.Diagnostics) sdkdiag.Diagnostics {
+	var converted sdkdiag.Diagnostics
+	for _, d := range diags {
+		severity := sdkdiag.Warning
+		if d.Severity() == diag.SeverityError {
+			severity = sdkdiag.Error
+		}
+		var attrPath cty.Path
+		if d, ok := d.(diag.DiagnosticWithPath); ok {
+			attrPath = ctyPath(d.Path())
+		}
+		converted = append(converted, sdkdiag.Diagnostic{
+			Severity:      severity,
+			Summary:       d.Summary(),
+			Detail:        d.Detail(),
+			AttributePath: attrPath,
+		})
+	}
+	return converted
+}
+
+// ctyPath converts a framework attribute path to an SDKv2 attribute path.
+func ctyPath(p path.Path) cty.Path {
+	var converted cty.Path
+	for _, step := range p.Steps() {
+		switch step := step.(type) {
+		case path.PathStepAttributeName:
+			converted = converted.GetAttr(string(step))
+		case path.PathStepElementKeyInt:
+			converted = converted.IndexInt(int(step))
+		case path.PathStepElementKeyString:
+			converted = converted.IndexString(string(step))
+		}
+	}
+	return converted
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+
+	dsschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+)
+
+// configEphemeralResource renders Butane configs as Ignition like the
+// ct_config data source, but its result is never persisted to plan or state
+// (e.g. for configs with secrets).
+type configEphemeralResource struct {
+	meta *providerMeta
+}
+
+var _ ephemeral.EphemeralResourceWithConfigure = &configEphemeralResource{}
+
+func NewConfigEphemeralResource() ephemeral.EphemeralResource {
+	return &configEphemeralResource{}
+}
+
+func (e *configEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_config"
+}
+
+func (e *configEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	// conversion errors are reported by GetProviderSchema (see TestProviderServer)
+	attrs, diags := ephemeralAttributes(configAttributes())
+	resp.Diagnostics.Append(diags...)
+	blocks, diags := ephemeralBlocks(configBlocks())
+	resp.Diagnostics.Append(diags...)
+	resp.Schema = schema.Schema{
+		Description: "Render a Butane config as Ignition without persisting it to plan or state",
+		Attributes:  attrs,
+		Blocks:      blocks,
+	}
+}
+
+// ephemeralAttributes converts data source attributes to ephemeral resource
+// attributes, whose types have the same fields.
+func ephemeralAttributes(attrs map[string]dsschema.Attribute) (map[string]schema.Attribute, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	converted := make(map[string]schema.Attribute, len(attrs))
+	for name, attr := range attrs {
+		switch attr := attr.(type) {
+		case dsschema.StringAttribute:
+			converted[name] = schema.StringAttribute(attr)
+		case dsschema.BoolAttribute:
+			converted[name] = schema.BoolAttribute(attr)
+		case dsschema.Int64Attribute:
+			converted[name] = schema.Int64Attribute(attr)
+		case dsschema.ListAttribute:
+			converted[name] = schema.ListAttribute(attr)
+		case dsschema.MapAttribute:
+			converted[name] = schema.MapAttribute(attr)
+		case dsschema.DynamicAttribute:
+			converted[name] = schema.DynamicAttribute(attr)
+		default:
+			diags.AddError("unsupported ephemeral attribute", fmt.Sprintf("attribute %s of type %T can't be converted", name, attr))
+		}
+	}
+	return converted, diags
+}
+
+// ephemeralBlocks converts data source blocks to ephemeral resource blocks.
+func ephemeralBlocks(blocks map[string]dsschema.Block) (map[string]schema.Block, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	converted := make(map[string]schema.Block, len(blocks))
+	for name, block := range blocks {
+		switch block := block.(type) {
+		case dsschema.ListNestedBlock:
+			attrs, attrDiags := ephemeralAttributes(block.NestedObject.Attributes)
+			nested, nestedDiags := ephemeralBlocks(block.NestedObject.Blocks)
+			diags.Append(attrDiags...)
+			diags.Append(nestedDiags...)
+			converted[name] = schema.ListNestedBlock{
+				NestedObject: schema.NestedBlockObject{
+					Attributes: attrs,
+					Blocks:     nested,
+					CustomType: block.NestedObject.CustomType,
+					Validators: block.NestedObject.Validators,
+				},
+				CustomType:          block.CustomType,
+				Description:         block.Description,
+				MarkdownDescription: block.MarkdownDescription,
+				DeprecationMessage:  block.DeprecationMessage,
+				Validators:          block.Validators,
+			}
+		default:
+			diags.AddError("unsupported ephemeral block", fmt.Sprintf("block %s of type %T can't be converted", name, block))
+		}
+	}
+	return converted, diags
+}
+
+func (e *configEphemeralResource) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	e.meta = req.ProviderData.(*providerMeta)
+}
+
+func (e *configEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var cfg configModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &cfg)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(cfg.render(ctx, e.meta)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &cfg)...)
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	r "github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+const ephemeralSecretContent = `---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      password_hash: $6$ephemeral-secret
+`
+
+var ephemeralConfig = fmt.Sprintf(`
+ephemeral "ct_config" "secret" {
+  content = <<EOT
+%sEOT
+}
+
+data "ct_config" "visible" {
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+EOT
+
+  lifecycle {
+    postcondition {
+      condition     = strcontains(ephemeral.ct_config.secret.rendered, "ephemeral-secret")
+      error_message = "ephemeral rendered lacks the password hash"
+    }
+    postcondition {
+      condition     = ephemeral.ct_config.secret.sha512 == "sha512-${sha512(ephemeral.ct_config.secret.rendered)}"
+      error_message = "ephemeral sha512 doesn't match rendered"
+    }
+  }
+}
+`, ephemeralSecretContent)
+
+const ephemeralConfigInvalid = `
+ephemeral "ct_config" "secret" {
+  strict = true
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      unknown_key: secret
+EOT
+}
+`
+
+func TestEphemeralConfig(t *testing.T) {
+	// Terraform's working directories, with their state files, are created
+	// here so the check can read them
+	tempDir := t.TempDir()
+	t.Setenv("TF_ACC_TEMP_DIR", tempDir)
+
+	secret := configModel{Content: types.StringValue(ephemeralSecretContent)}
+	if diags := secret.render(context.Background(), nil); diags.HasError() {
+		t.Fatalf("render: %v", diags)
+	}
+
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: ephemeralConfig,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttrSet("data.ct_config.visible", "rendered"),
+					testCheckNotPersisted(tempDir, "ephemeral-secret", secret.Rendered.ValueString(), secret.SHA512.ValueString()),
+				),
+			},
+			{
+				Config:      ephemeralConfigInvalid,
+				ExpectError: regexp.MustCompile(`strict parsing error: unused key unknown_key`),
+			},
+		},
+	})
+}
+
+// testCheckNotPersisted checks that the state files under a directory have
+// no mention of the values.
+func testCheckNotPersisted(dir string, values ...string) r.TestCheckFunc {
+	return func(*terraform.State) error {
+		var states int
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasPrefix(d.Name(), "terraform.tfstate") {
+				return err
+			}
+			states++
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			for _, value := range values {
+				if strings.Contains(string(data), value) {
+					return fmt.Errorf("%s contains %q", p, value)
+				}
+			}
+			return nil
+		})
+		if err == nil && states == 0 {
+			err = fmt.Errorf("no state files in %s", dir)
+		}
+		return err
+	}
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// keyedList is a list of an Ignition config whose entries are merged by key
+// (e.g. files by path), so a later input's entry replaces an earlier one.
+type keyedList struct {
+	// kind names entries in ids (e.g. file)
+	kind   string
+	fields []string
+	key    string
+}
+
+var keyedLists = []keyedList{
+	{kind: "file", fields: []string{"storage", "files"}, key: "path"},
+	{kind: "directory", fields: []string{"storage", "directories"}, key: "path"},
+	{kind: "link", fields: []string{"storage", "links"}, key: "path"},
+	{kind: "disk", fields: []string{"storage", "disks"}, key: "device"},
+	{kind: "filesystem", fields: []string{"storage", "filesystems"}, key: "device"},
+	{kind: "raid", fields: []string{"storage", "raid"}, key: "name"},
+	{kind: "luks", fields: []string{"storage", "luks"}, key: "name"},
+	{kind: "unit", fields: []string{"systemd", "units"}, key: "name"},
+	{kind: "user", fields: []string{"passwd", "users"}, key: "name"},
+	{kind: "group", fields: []string{"passwd", "groups"}, key: "name"},
+}
+
+// keyedEntry is an entry of a keyed list in an input's config.
+type keyedEntry struct {
+	// id identifies the entry across inputs (e.g. file:/etc/hostname)
+	id    string
+	in    input
+	path  string
+	value map[string]interface{}
+}
+
+// keyedEntries returns the keyed entries of the inputs' configs, grouped by
+// keyed list and in merge order within each list.
+func keyedEntries(sources []sourceValue) []keyedEntry {
+	var entries []keyedEntry
+	for _, list := range keyedLists {
+		for _, source := range sources {
+			for i, elem := range jsonList(source.value, list.fields...) {
+				entries = append(entries, keyedEntry{
+					id:    fmt.Sprintf("%s:%s", list.kind, jsonString(elem, list.key)),
+					in:    source.in,
+					path:  fmt.Sprintf("$.%s.%d", strings.Join(list.fields, "."), i),
+					value: elem,
+				})
+			}
+		}
+	}
+	return entries
+}
+
+// sourceValues converts the inputs' configs to generic JSON values.
+func sourceValues(sources []sourceConfig) ([]sourceValue, error) {
+	var values []sourceValue
+	for _, source := range sources {
+		value, err := toJSONValue(source.config)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, sourceValue{in: source.in, value: value})
+	}
+	return values, nil
+}
+
+// explained is a keyed entry of a merged config and the inputs that set it.
+type explained struct {
+	id string
+	// source is the input that last set the entry
+	source string
+	// overrides lists earlier inputs that set the entry
+	overrides []string
+}
+
+// explainConfig returns which input last set each keyed entry (e.g. file
+// path, unit, or user) of the merged config, in the order of keyedLists and
+// first appearance.
+func explainConfig(sources []sourceConfig) ([]explained, error) {
+	values, err := sourceValues(sources)
+	if err != nil {
+		return nil, err
+	}
+	var explanation []explained
+	index := map[string]int{}
+	for _, entry := range keyedEntries(values) {
+		i, ok := index[entry.id]
+		if !ok {
+			index[entry.id] = len(explanation)
+			explanation = append(explanation, explained{id: entry.id, source: entry.in.name})
+			continue
+		}
+		if prev := explanation[i].source; prev != entry.in.name {
+			explanation[i].overrides = append(explanation[i].overrides, prev)
+		}
+		explanation[i].source = entry.in.name
+	}
+	return explanation, nil
+}
+
+// flattenExplanation converts an explanation to values of the explanation
+// (map of id to input) and explanation_report attributes.
+func flattenExplanation(explanation []explained) (types.Map, types.String) {
+	elems := make(map[string]attr.Value, len(explanation))
+	var report strings.Builder
+	for _, e := range explanation {
+		elems[e.id] = types.StringValue(e.source)
+		fmt.Fprintf(&report, "%s: %s", e.id, e.source)
+		if len(e.overrides) > 0 {
+			fmt.Fprintf(&report, " (overrides %s)", strings.Join(e.overrides, ", "))
+		}
+		report.WriteString("\n")
+	}
+	return types.MapValueMust(types.StringType, elems), types.StringValue(report.String())
+}
@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// butaneToIgnitionFunction translates a Butane config to Ignition, like the
+// ct_config data source.
+type butaneToIgnitionFunction struct{}
+
+var _ function.Function = &butaneToIgnitionFunction{}
+
+func NewButaneToIgnitionFunction() function.Function {
+	return &butaneToIgnitionFunction{}
+}
+
+func (f *butaneToIgnitionFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "butane_to_ignition"
+}
+
+func (f *butaneToIgnitionFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Translate a Butane config to Ignition",
+		Description: "Validate a Butane config, merge snippets, and transpile it to an Ignition config, like the ct_config data source. Warnings are dropped, unless strict makes them errors.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "content",
+				Description: "contents of a Butane config",
+			},
+			function.DynamicParameter{
+				Name:           "options",
+				Description:    "object with optional strict, lint, conflict_policy, pretty_print, files_dir, snippets, and ignition_version (see ct_config)",
+				AllowNullValue: true,
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *butaneToIgnitionFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var content string
+	var options types.Dynamic
+	resp.Error = req.Arguments.Get(ctx, &content, &options)
+	if resp.Error != nil {
+		return
+	}
+
+	opts, err := parseFunctionOptions(ctx, options, butaneToIgnitionOptions...)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, err.Error())
+		return
+	}
+
+	// functions can't report warnings, so they're dropped unless strict
+	// makes them errors
+	ign, _, diags := butaneToIgnition([]byte(content), opts.pretty, opts.filesDir, opts.strict, opts.lint, opts.conflictPolicy, opts.snippets, nil, ignitionSpecs[opts.ignitionVersion], nil)
+	if diags.HasError() {
+		resp.Error = function.FuncErrorFromDiags(ctx, diags)
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, string(ign))
+}
+
+// functionOptions are the options of the butane_to_ignition and
+// merge_ignition functions.
+type functionOptions struct {
+	strict          bool
+	lint            bool
+	conflictPolicy  string
+	pretty          bool
+	filesDir        string
+	snippets        []snippet
+	ignitionVersion string
+}
+
+var butaneToIgnitionOptions = []string{"strict", "lint", "conflict_policy", "pretty_print", "files_dir", "snippets", "ignition_version"}
+
+// parseFunctionOptions reads the supported function options from an object
+// or map, which may omit any option.
+func parseFunctionOptions(ctx context.Context, options types.Dynamic, supported ...string) (functionOptions, error) {
+	opts := functionOptions{
+		ignitionVersion: defaultIgnitionVersion,
+	}
+	if options.IsNull() || options.IsUnderlyingValueNull() {
+		return opts, nil
+	}
+
+	value, err := options.UnderlyingValue().ToTerraformValue(ctx)
+	if err != nil {
+		return opts, err
+	}
+	var attrs map[string]tftypes.Value
+	if err := value.As(&attrs); err != nil {
+		return opts, fmt.Errorf("options must be an object: %v", err)
+	}
+
+	for name, attr := range attrs {
+		if !slices.Contains(supported, name) {
+			return opts, fmt.Errorf("invalid option %s: unsupported option", name)
+		}
+		if attr.IsNull() {
+			continue
+		}
+		switch name {
+		case "strict":
+			err = attr.As(&opts.strict)
+		case "lint":
+			err = attr.As(&opts.lint)
+		case "conflict_policy":
+			err = attr.As(&opts.conflictPolicy)
+			if err == nil && !slices.Contains(conflictPolicies, opts.conflictPolicy) {
+				err = fmt.Errorf("expected one of %v, got %s", conflictPolicies, opts.conflictPolicy)
+			}
+		case "pretty_print":
+			err = attr.As(&opts.pretty)
+		case "files_dir":
+			err = attr.As(&opts.filesDir)
+		case "ignition_version":
+			err = attr.As(&opts.ignitionVersion)
+			if err == nil && ignitionSpecs[opts.ignitionVersion] == nil {
+				err = fmt.Errorf("expected one of %v, got %s", ignitionVersions(), opts.ignitionVersion)
+			}
+		case "snippets":
+			opts.snippets, err = parseSnippets(attr)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("invalid option %s: %v", name, err)
+		}
+	}
+	return opts, nil
+}
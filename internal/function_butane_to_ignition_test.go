@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"regexp"
+	"testing"
+
+	r "github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+const butaneToIgnitionFunctionConfig = testFunctionProviders + `
+output "rendered" {
+  value = provider::ct::butane_to_ignition(<<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - key
+EOT
+  , {
+    strict = true
+    snippets = [
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      enabled: true
+EOT
+    ]
+  })
+}
+`
+
+const butaneToIgnitionFunctionNoOptions = testFunctionProviders + `
+output "rendered" {
+  value = provider::ct::butane_to_ignition("variant: fcos\nversion: 1.5.0\nunknown_key: true", null)
+}
+`
+
+const butaneToIgnitionFunctionStrict = testFunctionProviders + `
+output "rendered" {
+  value = provider::ct::butane_to_ignition("variant: fcos\nversion: 1.5.0\nunknown_key: true", { strict = true })
+}
+`
+
+const butaneToIgnitionFunctionInvalidOption = testFunctionProviders + `
+output "rendered" {
+  value = provider::ct::butane_to_ignition("variant: fcos\nversion: 1.5.0", { stric = true })
+}
+`
+
+func TestButaneToIgnitionFunction(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: butaneToIgnitionFunctionConfig,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckOutput("rendered", ignitionV34WithSnippetsPrettyFalseExpected),
+				),
+			},
+			{
+				Config: butaneToIgnitionFunctionNoOptions,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckOutput("rendered", `{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.4.0"},"kernelArguments":{},"passwd":{},"storage":{},"systemd":{}}`),
+				),
+			},
+			{
+				Config:      butaneToIgnitionFunctionStrict,
+				ExpectError: regexp.MustCompile(`strict parsing\s+error: unused key unknown_key`),
+			},
+			{
+				Config:      butaneToIgnitionFunctionInvalidOption,
+				ExpectError: regexp.MustCompile(`invalid option stric: unsupported\s+option`),
+			},
+		},
+	})
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+// ignitionToButaneFunction translates an Ignition config to Butane, like the
+// ct_ignition_to_butane data source.
+type ignitionToButaneFunction struct{}
+
+var _ function.Function = &ignitionToButaneFunction{}
+
+func NewIgnitionToButaneFunction() function.Function {
+	return &ignitionToButaneFunction{}
+}
+
+func (f *ignitionToButaneFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "ignition_to_butane"
+}
+
+func (f *ignitionToButaneFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Translate an Ignition config to Butane",
+		Description: "Translate an Ignition JSON config to an equivalent Butane YAML config of a variant and version, like the ct_ignition_to_butane data source.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "content",
+				Description: "Ignition JSON config to translate",
+			},
+			function.StringParameter{
+				Name:        "variant",
+				Description: "Butane variant of the translated config (e.g. fcos, flatcar)",
+			},
+			function.StringParameter{
+				Name:        "version",
+				Description: "Butane version of the translated config (e.g. 1.5.0)",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *ignitionToButaneFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var content, variant, version string
+	resp.Error = req.Arguments.Get(ctx, &content, &variant, &version)
+	if resp.Error != nil {
+		return
+	}
+
+	out, diags := ignitionToButane([]byte(content), variant, version)
+	if diags.HasError() {
+		resp.Error = function.FuncErrorFromDiags(ctx, diags)
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, string(out))
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"regexp"
+	"testing"
+
+	r "github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+const ignitionToButaneFunctionConfig = testFunctionProviders + `
+output "butane" {
+  value = provider::ct::ignition_to_butane(
+    jsonencode({ ignition = { version = "3.4.0" }, passwd = { users = [{ name = "core", sshAuthorizedKeys = ["key"] }] } }),
+    "flatcar",
+    "1.1.0",
+  )
+}
+`
+
+const ignitionToButaneFunctionExpected = `---
+variant: flatcar
+version: 1.1.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - key
+`
+
+const ignitionToButaneFunctionInvalid = testFunctionProviders + `
+output "butane" {
+  value = provider::ct::ignition_to_butane("{}", "fcos", "9.0.0")
+}
+`
+
+func TestIgnitionToButaneFunction(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: ignitionToButaneFunctionConfig,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckOutput("butane", ignitionToButaneFunctionExpected),
+				),
+			},
+			{
+				Config:      ignitionToButaneFunctionInvalid,
+				ExpectError: regexp.MustCompile(`unsupported\s+Butane variant or version`),
+			},
+		},
+	})
+}
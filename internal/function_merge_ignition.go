@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// mergeIgnitionFunction merges Ignition configs, like the ct_ignition_merge
+// data source.
+type mergeIgnitionFunction struct{}
+
+var _ function.Function = &mergeIgnitionFunction{}
+
+var mergeIgnitionOptions = []string{"pretty_print", "ignition_version"}
+
+func NewMergeIgnitionFunction() function.Function {
+	return &mergeIgnitionFunction{}
+}
+
+func (f *mergeIgnitionFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "merge_ignition"
+}
+
+func (f *mergeIgnitionFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Merge Ignition configs",
+		Description: "Merge a list of Ignition JSON configs in order, like the ct_ignition_merge data source. Warnings are dropped.",
+		Parameters: []function.Parameter{
+			function.ListParameter{
+				Name:        "configs",
+				Description: "Ignition JSON configs to merge, in order",
+				ElementType: types.StringType,
+			},
+			function.DynamicParameter{
+				Name:           "options",
+				Description:    "object with optional pretty_print and ignition_version (see ct_ignition_merge)",
+				AllowNullValue: true,
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *mergeIgnitionFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var configs []string
+	var options types.Dynamic
+	resp.Error = req.Arguments.Get(ctx, &configs, &options)
+	if resp.Error != nil {
+		return
+	}
+	if len(configs) == 0 {
+		resp.Error = function.NewArgumentFuncError(0, "configs must contain at least one Ignition config")
+		return
+	}
+	opts, err := parseFunctionOptions(ctx, options, mergeIgnitionOptions...)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, err.Error())
+		return
+	}
+
+	ign, diags := mergeIgnition(configs, opts.pretty, ignitionSpecs[opts.ignitionVersion])
+	if diags.HasError() {
+		resp.Error = function.FuncErrorFromDiags(ctx, diags)
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, string(ign))
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"regexp"
+	"testing"
+
+	r "github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+const mergeIgnitionFunctionConfig = testFunctionProviders + `
+output "rendered" {
+  value = provider::ct::merge_ignition([
+    jsonencode({ ignition = { version = "3.3.0" }, passwd = { users = [{ name = "core", sshAuthorizedKeys = ["key"] }] } }),
+    jsonencode({ ignition = { version = "3.4.0" }, systemd = { units = [{ name = "docker.service", enabled = true }] } }),
+  ], null)
+}
+`
+
+const mergeIgnitionFunctionVersion = testFunctionProviders + `
+output "rendered" {
+  value = provider::ct::merge_ignition([
+    jsonencode({ ignition = { version = "3.4.0" }, systemd = { units = [{ name = "docker.service", enabled = true }] } }),
+  ], { ignition_version = "3.3.0" })
+}
+`
+
+const mergeIgnitionFunctionInvalidOption = testFunctionProviders + `
+output "rendered" {
+  value = provider::ct::merge_ignition(["{}"], { strict = true })
+}
+`
+
+const mergeIgnitionFunctionInvalid = testFunctionProviders + `
+output "rendered" {
+  value = provider::ct::merge_ignition(["{}"], null)
+}
+`
+
+func TestMergeIgnitionFunction(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: mergeIgnitionFunctionConfig,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckOutput("rendered", ignitionV34WithSnippetsPrettyFalseExpected),
+				),
+			},
+			{
+				Config: mergeIgnitionFunctionVersion,
+				Check: r.ComposeTestCheckFunc(
+					r.TestMatchOutput("rendered", regexp.MustCompile(`"version":"3.3.0"`)),
+				),
+			},
+			{
+				Config:      mergeIgnitionFunctionInvalidOption,
+				ExpectError: regexp.MustCompile(`invalid option strict: unsupported\s+option`),
+			},
+			{
+				Config:      mergeIgnitionFunctionInvalid,
+				ExpectError: regexp.MustCompile(`configs\[0\]`),
+			},
+		},
+	})
+}
@@ -1,26 +1,27 @@
 package internal
 
 import (
-	"hash/crc32"
-	"strconv"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 )
 
-// Migrated from the V1 SDK github.com/hashicorp/terraform-plugin-sdk/helper/hashcode
-// https://www.terraform.io/plugin/sdkv2/guides/v2-upgrade-guide#removal-of-helper-hashcode-package
-
-// Hashcode hashes a string to a unique hashcode.
-//
-// crc32 returns a uint32, but for our use we need
-// and non negative integer. Here we cast to an integer
-// and invert it if the result is negative.
+// hashcode hashes a string to a unique hex-encoded SHA-256 digest, suitable
+// as a data source ID.
 func hashcode(s string) string {
-	v := int(crc32.ChecksumIEEE([]byte(s)))
-	if v >= 0 {
-		return strconv.Itoa(v)
-	}
-	if -v >= 0 {
-		return strconv.Itoa(-v)
-	}
-	// v == MinInt
-	return "0"
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// sha256Digest returns the SHA-256 digest of data in Ignition's
+// verification.hash format (sha256-<hex>).
+func sha256Digest(data []byte) string {
+	return "sha256-" + hashcode(string(data))
+}
+
+// sha512Digest returns the SHA-512 digest of data in Ignition's
+// verification.hash format (sha512-<hex>).
+func sha512Digest(data []byte) string {
+	sum := sha512.Sum512(data)
+	return "sha512-" + hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,199 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coreos/ignition/v2/config/shared/errors"
+	"github.com/coreos/ignition/v2/config/v3_0"
+	"github.com/coreos/ignition/v2/config/v3_1"
+	"github.com/coreos/ignition/v2/config/v3_2"
+	"github.com/coreos/ignition/v2/config/v3_3"
+	"github.com/coreos/ignition/v2/config/v3_4"
+	"github.com/coreos/ignition/v2/config/v3_5"
+	"github.com/coreos/ignition/v2/config/v3_6"
+	"github.com/coreos/vcontext/report"
+)
+
+// defaultIgnitionVersion is the Ignition spec version rendered by default.
+const defaultIgnitionVersion = "3.4.0"
+
+// ignitionSpecs maps supported Ignition spec versions to their config package.
+var ignitionSpecs = map[string]ignitionSpec{
+	"3.0.0": newSpec("3.0.0", v3_0.ParseCompatibleVersion, v3_0.Merge),
+	"3.1.0": newSpec("3.1.0", v3_1.ParseCompatibleVersion, v3_1.Merge),
+	"3.2.0": newSpec("3.2.0", v3_2.ParseCompatibleVersion, v3_2.Merge),
+	"3.3.0": newSpec("3.3.0", v3_3.ParseCompatibleVersion, v3_3.Merge),
+	"3.4.0": newSpec("3.4.0", v3_4.ParseCompatibleVersion, v3_4.Merge),
+	"3.5.0": newSpec("3.5.0", v3_5.ParseCompatibleVersion, v3_5.Merge),
+	"3.6.0": newSpec("3.6.0", v3_6.ParseCompatibleVersion, v3_6.Merge),
+}
+
+// ignitionVersions lists supported Ignition spec versions in ascending order.
+func ignitionVersions() []string {
+	versions := make([]string, 0, len(ignitionSpecs))
+	for version := range ignitionSpecs {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// ignitionSpec parses and merges Ignition configs of a target spec version.
+type ignitionSpec interface {
+	// Parse parses Ignition JSON of any supported spec version into a config
+	// of the target version, with a report of validation entries.
+	Parse(data []byte) (interface{}, report.Report, error)
+	// Merge merges child into parent, both of the target version.
+	Merge(parent, child interface{}) interface{}
+	// Version returns the target spec version.
+	Version() string
+}
+
+// spec implements ignitionSpec using the functions of a config/v3_x package.
+type spec[T any] struct {
+	version string
+	parse   func([]byte) (T, report.Report, error)
+	merge   func(T, T) T
+}
+
+func newSpec[T any](version string, parse func([]byte) (T, report.Report, error), merge func(T, T) T) spec[T] {
+	return spec[T]{version: version, parse: parse, merge: merge}
+}
+
+func (s spec[T]) Parse(data []byte) (interface{}, report.Report, error) {
+	cfg, rpt, err := s.parse(data)
+	if err == errors.ErrUnknownVersion {
+		return s.downgrade(data)
+	}
+	if err != nil {
+		return nil, rpt, err
+	}
+	return cfg, rpt, nil
+}
+
+func (s spec[T]) Merge(parent, child interface{}) interface{} {
+	return s.merge(parent.(T), child.(T))
+}
+
+func (s spec[T]) Version() string {
+	return s.version
+}
+
+// downgrade parses an Ignition config newer than the target spec version.
+// The config is rewritten as the target version, failing if it uses fields
+// that are unavailable in the target spec.
+func (s spec[T]) downgrade(data []byte) (interface{}, report.Report, error) {
+	latest, rpt, err := v3_6.ParseCompatibleVersion(data)
+	if err != nil {
+		return nil, rpt, err
+	}
+	src, err := toJSONValue(latest)
+	if err != nil {
+		return nil, rpt, err
+	}
+	src.(map[string]interface{})["ignition"].(map[string]interface{})["version"] = s.version
+	// empty fields of the latest spec would be reported as unused keys
+	src = prune(src)
+
+	raw, err := json.Marshal(src)
+	if err != nil {
+		return nil, rpt, err
+	}
+	// keep warnings about the input, such as unused keys, which the
+	// rewritten config no longer has
+	cfg, downgraded, err := s.parse(raw)
+	rpt.Merge(downgraded)
+	if err != nil {
+		return nil, rpt, err
+	}
+	dst, err := toJSONValue(cfg)
+	if err != nil {
+		return nil, rpt, err
+	}
+
+	if missing := missingFields(src, prune(dst), ""); len(missing) > 0 {
+		return nil, rpt, fmt.Errorf("config uses fields unavailable in Ignition %s: %s", s.version, strings.Join(missing, ", "))
+	}
+	return cfg, rpt, nil
+}
+
+// toJSONValue converts v to its generic JSON representation.
+func toJSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
+
+// prune removes nulls, empty objects, and empty arrays (including their
+// elements) from a generic JSON value, returning nil if nothing remains.
+func prune(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if value = prune(value); value == nil {
+				delete(v, key)
+			} else {
+				v[key] = value
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		var elems []interface{}
+		for _, value := range v {
+			if value = prune(value); value != nil {
+				elems = append(elems, value)
+			}
+		}
+		if len(elems) == 0 {
+			return nil
+		}
+		return elems
+	}
+	return v
+}
+
+// missingFields returns the paths of fields in src that are absent from dst.
+func missingFields(src, dst interface{}, path string) []string {
+	var missing []string
+	switch src := src.(type) {
+	case map[string]interface{}:
+		dst, _ := dst.(map[string]interface{})
+		keys := make([]string, 0, len(src))
+		for key := range src {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			field := key
+			if path != "" {
+				field = path + "." + key
+			}
+			value, ok := dst[key]
+			if !ok {
+				missing = append(missing, field)
+				continue
+			}
+			missing = append(missing, missingFields(src[key], value, field)...)
+		}
+	case []interface{}:
+		dst, _ := dst.([]interface{})
+		for i, value := range src {
+			field := fmt.Sprintf("%s[%d]", path, i)
+			if i >= len(dst) {
+				missing = append(missing, field)
+				continue
+			}
+			missing = append(missing, missingFields(value, dst[i], field)...)
+		}
+	}
+	return missing
+}
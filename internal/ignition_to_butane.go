@@ -0,0 +1,298 @@
+package internal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/vincent-petithory/dataurl"
+	"gopkg.in/yaml.v3"
+
+	butane "github.com/coreos/butane/config"
+	"github.com/coreos/butane/config/common"
+)
+
+// Translate an Ignition config to an equivalent Butane config of the given
+// variant and version.
+func ignitionToButane(content []byte, variant, version string) ([]byte, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	target, err := butaneIgnitionVersion(variant, version)
+	if err != nil {
+		diags.AddError("unsupported Butane variant or version", err.Error())
+		return nil, diags
+	}
+	spec, ok := ignitionSpecs[target]
+	if !ok {
+		diags.AddError("unsupported Butane variant or version", fmt.Sprintf("%s %s translates to unsupported Ignition %s", variant, version, target))
+		return nil, diags
+	}
+
+	ign, report, err := spec.Parse(content)
+	diags = append(diags, reportDiagnostics(report, contentInput, false)...)
+	if err != nil {
+		return nil, append(diags, contentInput.errorDiagnostic("Ignition parse error", err))
+	}
+	value, err := toJSONValue(ign)
+	if err != nil {
+		diags.AddError("Ignition marshal error", err.Error())
+		return nil, diags
+	}
+	config, _ := prune(value).(map[string]interface{})
+	if ignition, ok := config["ignition"].(map[string]interface{}); ok {
+		delete(ignition, "version")
+		if len(ignition) == 0 {
+			delete(config, "ignition")
+		}
+	}
+
+	config, ok = butaneKeys(inlineDataURLs(config)).(map[string]interface{})
+	if !ok {
+		config = map[string]interface{}{}
+	}
+	out, err := butaneYAML(config, variant, version)
+	if err != nil {
+		diags.AddError("Butane marshal error", err.Error())
+		return nil, diags
+	}
+
+	// translating the result must give back the input config
+	in := input{name: fmt.Sprintf("%s %s Butane", variant, version), path: contentInput.path}
+	translated, report, err := butane.TranslateBytes(out, common.TranslateBytesOptions{})
+	diags = append(diags, reportDiagnostics(report, in, false)...)
+	if err != nil {
+		return nil, append(diags, in.errorDiagnostic("Butane translate error", err))
+	}
+	if diags.HasError() {
+		return nil, diags
+	}
+	if err := checkRoundTrip(ign, translated, spec); err != nil {
+		return nil, append(diags, in.errorDiagnostic("Butane round-trip error", err))
+	}
+	return out, diags
+}
+
+// checkRoundTrip checks that an Ignition config translated from Butane is
+// equivalent to the original config. Empty fields are ignored and data URLs
+// are compared by their decoded contents, since Butane encodes inline
+// contents its own way (e.g. gzip compressed).
+func checkRoundTrip(original interface{}, translated []byte, spec ignitionSpec) error {
+	cfg, _, err := spec.Parse(translated)
+	if err != nil {
+		return err
+	}
+	var values [2]interface{}
+	for i, config := range []interface{}{original, cfg} {
+		value, err := toJSONValue(config)
+		if err != nil {
+			return err
+		}
+		values[i] = inlineDataURLs(prune(value))
+	}
+	if !reflect.DeepEqual(values[0], values[1]) {
+		return fmt.Errorf("translating the Butane config gives a different Ignition config")
+	}
+	return nil
+}
+
+// butaneIgnitionVersion returns the Ignition spec version that a Butane
+// variant and version translate to.
+func butaneIgnitionVersion(variant, version string) (string, error) {
+	ign, _, err := butane.TranslateBytes([]byte(fmt.Sprintf("variant: %s\nversion: %s\n", variant, version)), common.TranslateBytesOptions{})
+	if err != nil {
+		return "", err
+	}
+	var config struct {
+		Ignition struct {
+			Version string `json:"version"`
+		} `json:"ignition"`
+	}
+	if err := json.Unmarshal(ign, &config); err != nil {
+		return "", err
+	}
+	return config.Ignition.Version, nil
+}
+
+// inlineDataURLs replaces data URL sources of Ignition resources (e.g. file
+// contents) with inline text where possible. Resources with verification
+// hashes or non-text data are left unchanged.
+func inlineDataURLs(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = inlineDataURLs(value)
+		}
+		source, ok := v["source"].(string)
+		if !ok || !strings.HasPrefix(source, "data:") || v["verification"] != nil {
+			return v
+		}
+		data, err := dataurl.DecodeString(source)
+		if err != nil {
+			return v
+		}
+		text := data.Data
+		switch v["compression"] {
+		case "gzip":
+			if text, err = gunzip(text); err != nil {
+				return v
+			}
+		case nil, "":
+			// uncompressed, as Ignition treats an empty compression
+		default:
+			return v
+		}
+		if !utf8.Valid(text) {
+			return v
+		}
+		delete(v, "source")
+		delete(v, "compression")
+		v["inline"] = string(text)
+	case []interface{}:
+		for i, value := range v {
+			v[i] = inlineDataURLs(value)
+		}
+	}
+	return v
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// butaneKeys renames Ignition JSON fields (e.g. sshAuthorizedKeys) to their
+// Butane YAML fields (e.g. ssh_authorized_keys).
+func butaneKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			renamed[snakeCase(key)] = butaneKeys(value)
+		}
+		return renamed
+	case []interface{}:
+		for i, value := range v {
+			v[i] = butaneKeys(value)
+		}
+	}
+	return v
+}
+
+// snakeCase converts an Ignition field name to snake case, keeping the MiB
+// unit as one word (e.g. sizeMiB to size_mib).
+func snakeCase(key string) string {
+	key = strings.ReplaceAll(key, "MiB", "Mib")
+	var b strings.Builder
+	for i, r := range key {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// butaneYAML marshals a Butane config, with variant and version first.
+func butaneYAML(config map[string]interface{}, variant, version string) ([]byte, error) {
+	root, err := yamlNode(config)
+	if err != nil {
+		return nil, err
+	}
+	root.Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "variant"},
+		{Kind: yaml.ScalarNode, Value: variant},
+		{Kind: yaml.ScalarNode, Value: "version"},
+		{Kind: yaml.ScalarNode, Value: version},
+	}, root.Content...)
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// leadingKeys are listed first in Butane mappings, to read like hand-written
+// configs (e.g. a file's path before its contents).
+var leadingKeys = []string{"name", "path", "device", "label"}
+
+// yamlNode converts a generic JSON value to a YAML node, with mapping keys
+// in a stable order and file modes in octal (e.g. 0644).
+func yamlNode(v interface{}) (*yaml.Node, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.SliceStable(keys, func(i, j int) bool {
+			ri, rj := keyRank(keys[i]), keyRank(keys[j])
+			if ri != rj {
+				return ri < rj
+			}
+			return keys[i] < keys[j]
+		})
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, key := range keys {
+			value, err := yamlNode(v[key])
+			if err != nil {
+				return nil, err
+			}
+			if mode, ok := v[key].(float64); ok && key == "mode" {
+				value.Value = fmt.Sprintf("0%o", int(mode))
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+		}
+		return node, nil
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, elem := range v {
+			value, err := yamlNode(elem)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		return node, nil
+	case float64:
+		// JSON numbers in Ignition configs are integers (e.g. sizes, uids)
+		node := &yaml.Node{}
+		err := node.Encode(int64(v))
+		return node, err
+	default:
+		node := &yaml.Node{}
+		err := node.Encode(v)
+		return node, err
+	}
+}
+
+// keyRank orders leadingKeys before other keys.
+func keyRank(key string) int {
+	for i, leading := range leadingKeys {
+		if key == leading {
+			return i
+		}
+	}
+	return len(leadingKeys)
+}
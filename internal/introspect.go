@@ -0,0 +1,196 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ignitionContents holds the fields of a rendered Ignition config that are
+// described by introspection attributes. Fields are common to all Ignition
+// v3 specs.
+type ignitionContents struct {
+	Passwd struct {
+		Users []struct {
+			Name   string   `json:"name"`
+			Groups []string `json:"groups"`
+		} `json:"users"`
+	} `json:"passwd"`
+	Storage struct {
+		Disks []struct {
+			Device     string `json:"device"`
+			Partitions []struct {
+				Label *string `json:"label"`
+			} `json:"partitions"`
+		} `json:"disks"`
+		Files []struct {
+			Path  string        `json:"path"`
+			Mode  *int64        `json:"mode"`
+			User  ignitionOwner `json:"user"`
+			Group ignitionOwner `json:"group"`
+		} `json:"files"`
+		Filesystems []struct {
+			Device string  `json:"device"`
+			Format *string `json:"format"`
+			Path   *string `json:"path"`
+		} `json:"filesystems"`
+		Luks []struct {
+			Name   string  `json:"name"`
+			Device *string `json:"device"`
+		} `json:"luks"`
+	} `json:"storage"`
+	Systemd struct {
+		Units []struct {
+			Name    string `json:"name"`
+			Enabled *bool  `json:"enabled"`
+		} `json:"units"`
+	} `json:"systemd"`
+}
+
+// ignitionOwner is a file's user or group, by id or name.
+type ignitionOwner struct {
+	ID   *int64  `json:"id"`
+	Name *string `json:"name"`
+}
+
+// String returns the owner's name, falling back to its id.
+func (o ignitionOwner) String() *string {
+	if o.Name != nil {
+		return o.Name
+	}
+	if o.ID != nil {
+		id := strconv.FormatInt(*o.ID, 10)
+		return &id
+	}
+	return nil
+}
+
+// Element types of the introspection attributes.
+var (
+	fileType = types.ObjectType{AttrTypes: map[string]attr.Type{
+		"path":  types.StringType,
+		"mode":  types.Int64Type,
+		"owner": types.StringType,
+		"group": types.StringType,
+	}}
+	systemdUnitType = types.ObjectType{AttrTypes: map[string]attr.Type{
+		"name":    types.StringType,
+		"enabled": types.BoolType,
+	}}
+	userType = types.ObjectType{AttrTypes: map[string]attr.Type{
+		"name":   types.StringType,
+		"groups": types.ListType{ElemType: types.StringType},
+	}}
+	filesystemType = types.ObjectType{AttrTypes: map[string]attr.Type{
+		"device": types.StringType,
+		"format": types.StringType,
+		"path":   types.StringType,
+	}}
+	diskType = types.ObjectType{AttrTypes: map[string]attr.Type{
+		"device":     types.StringType,
+		"partitions": types.ListType{ElemType: types.StringType},
+	}}
+	luksType = types.ObjectType{AttrTypes: map[string]attr.Type{
+		"name":   types.StringType,
+		"device": types.StringType,
+	}}
+)
+
+type fileModel struct {
+	Path  string  `tfsdk:"path"`
+	Mode  *int64  `tfsdk:"mode"`
+	Owner *string `tfsdk:"owner"`
+	Group *string `tfsdk:"group"`
+}
+
+type systemdUnitModel struct {
+	Name    string `tfsdk:"name"`
+	Enabled *bool  `tfsdk:"enabled"`
+}
+
+type userModel struct {
+	Name   string   `tfsdk:"name"`
+	Groups []string `tfsdk:"groups"`
+}
+
+type filesystemModel struct {
+	Device string  `tfsdk:"device"`
+	Format *string `tfsdk:"format"`
+	Path   *string `tfsdk:"path"`
+}
+
+type diskModel struct {
+	Device     string   `tfsdk:"device"`
+	Partitions []string `tfsdk:"partitions"`
+}
+
+type luksModel struct {
+	Name   string  `tfsdk:"name"`
+	Device *string `tfsdk:"device"`
+}
+
+// introspect sets the attributes describing the contents of a rendered
+// Ignition config (files, units, users, and storage).
+func (cfg *configModel) introspect(ctx context.Context, rendered string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	var contents ignitionContents
+	if err := json.Unmarshal([]byte(rendered), &contents); err != nil {
+		diags.AddError("Ignition unmarshal error", err.Error())
+		return diags
+	}
+
+	files := []fileModel{}
+	for _, f := range contents.Storage.Files {
+		files = append(files, fileModel{Path: f.Path, Mode: f.Mode, Owner: f.User.String(), Group: f.Group.String()})
+	}
+	units := []systemdUnitModel{}
+	for _, u := range contents.Systemd.Units {
+		units = append(units, systemdUnitModel{Name: u.Name, Enabled: u.Enabled})
+	}
+	users := []userModel{}
+	for _, u := range contents.Passwd.Users {
+		groups := u.Groups
+		if groups == nil {
+			groups = []string{}
+		}
+		users = append(users, userModel{Name: u.Name, Groups: groups})
+	}
+	filesystems := []filesystemModel{}
+	for _, fs := range contents.Storage.Filesystems {
+		filesystems = append(filesystems, filesystemModel{Device: fs.Device, Format: fs.Format, Path: fs.Path})
+	}
+	disks := []diskModel{}
+	for _, d := range contents.Storage.Disks {
+		// unlabeled partitions are omitted
+		labels := []string{}
+		for _, p := range d.Partitions {
+			if p.Label != nil {
+				labels = append(labels, *p.Label)
+			}
+		}
+		disks = append(disks, diskModel{Device: d.Device, Partitions: labels})
+	}
+	luks := []luksModel{}
+	for _, l := range contents.Storage.Luks {
+		luks = append(luks, luksModel{Name: l.Name, Device: l.Device})
+	}
+
+	var d diag.Diagnostics
+	cfg.Files, d = types.ListValueFrom(ctx, fileType, files)
+	diags.Append(d...)
+	cfg.SystemdUnits, d = types.ListValueFrom(ctx, systemdUnitType, units)
+	diags.Append(d...)
+	cfg.Users, d = types.ListValueFrom(ctx, userType, users)
+	diags.Append(d...)
+	cfg.Filesystems, d = types.ListValueFrom(ctx, filesystemType, filesystems)
+	diags.Append(d...)
+	cfg.Disks, d = types.ListValueFrom(ctx, diskType, disks)
+	diags.Append(d...)
+	cfg.Luks, d = types.ListValueFrom(ctx, luksType, luks)
+	diags.Append(d...)
+	return diags
+}
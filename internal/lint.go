@@ -0,0 +1,242 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// sourceConfig is the Ignition config of an input, before merging.
+type sourceConfig struct {
+	in     input
+	config interface{}
+}
+
+// lintIssue is a likely mistake in a config that Butane and Ignition
+// validation accept.
+type lintIssue struct {
+	in      input
+	path    string
+	message string
+	// advisory issues may be false positives (e.g. dropins for units the OS
+	// provides), so strict doesn't make them errors
+	advisory bool
+}
+
+// lintConfig checks a merged config and the configs of its inputs for common
+// mistakes: enabled units without an [Install] section, dropins for units
+// the config doesn't define, files overwritten with different contents, and
+// users who can't log in.
+func lintConfig(merged interface{}, sources []sourceConfig) ([]lintIssue, error) {
+	config, err := toJSONValue(merged)
+	if err != nil {
+		return nil, err
+	}
+	values, err := sourceValues(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []lintIssue
+	issues = append(issues, lintUnits(config, values)...)
+	issues = append(issues, lintOverwrittenFiles(values)...)
+	issues = append(issues, lintUsers(config, values)...)
+	return issues, nil
+}
+
+// sourceValue is the generic JSON value of an input's config.
+type sourceValue struct {
+	in    input
+	value interface{}
+}
+
+func lintUnits(config interface{}, sources []sourceValue) []lintIssue {
+	var issues []lintIssue
+	defined := map[string]bool{}
+	for _, unit := range jsonList(config, "systemd", "units") {
+		if contents, _ := unit["contents"].(string); contents != "" {
+			defined[jsonString(unit, "name")] = true
+		}
+	}
+	for _, file := range jsonList(config, "storage", "files") {
+		if dir, name := path.Split(jsonString(file, "path")); dir == "/etc/systemd/system/" {
+			defined[name] = true
+		}
+	}
+
+	for _, unit := range jsonList(config, "systemd", "units") {
+		name := jsonString(unit, "name")
+		contents, _ := unit["contents"].(string)
+		in, at := definedBy(sources, name, "name", "systemd", "units")
+		// Butane already warns about units enabled in the input with their contents
+		if enabled, _ := unit["enabled"].(bool); enabled && contents != "" && !hasInstallSection(unit) && !enabledWithContents(sources, name) {
+			issues = append(issues, lintIssue{in: in, path: at, message: fmt.Sprintf("unit %s is enabled, but has no [Install] section", name)})
+		}
+		if len(jsonList(unit, "dropins")) > 0 && !defined[name] {
+			issues = append(issues, lintIssue{in: in, path: at, message: fmt.Sprintf("dropins for unit %s, which the config doesn't define", name), advisory: true})
+		}
+	}
+
+	// dropins written as files (e.g. /etc/systemd/system/foo.service.d/10-foo.conf)
+	for _, file := range jsonList(config, "storage", "files") {
+		filePath := jsonString(file, "path")
+		dir, _ := path.Split(filePath)
+		parent, unitDir := path.Split(strings.TrimSuffix(dir, "/"))
+		if parent != "/etc/systemd/system/" || !strings.HasSuffix(unitDir, ".d") {
+			continue
+		}
+		name := strings.TrimSuffix(unitDir, ".d")
+		if !defined[name] && !isTypeDropinDir(name) {
+			in, at := definedBy(sources, filePath, "path", "storage", "files")
+			issues = append(issues, lintIssue{in: in, path: at, message: fmt.Sprintf("dropin %s for unit %s, which the config doesn't define", filePath, name), advisory: true})
+		}
+	}
+	return issues
+}
+
+// isTypeDropinDir reports whether a drop-in directory name applies to many
+// units, either of a type (e.g. service.d) or with a prefix (e.g.
+// foo-.service.d), rather than naming a unit.
+func isTypeDropinDir(name string) bool {
+	return !strings.Contains(name, ".") || strings.Contains(name, "-.")
+}
+
+// enabledWithContents reports whether an input enables a unit and sets its
+// contents.
+func enabledWithContents(sources []sourceValue, name string) bool {
+	for _, source := range sources {
+		for _, unit := range jsonList(source.value, "systemd", "units") {
+			enabled, _ := unit["enabled"].(bool)
+			if jsonString(unit, "name") == name && enabled && jsonString(unit, "contents") != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasInstallSection reports whether a unit or its dropins have an [Install]
+// section.
+func hasInstallSection(unit map[string]interface{}) bool {
+	contents := []string{jsonString(unit, "contents")}
+	for _, dropin := range jsonList(unit, "dropins") {
+		contents = append(contents, jsonString(dropin, "contents"))
+	}
+	for _, c := range contents {
+		for _, line := range strings.Split(c, "\n") {
+			if strings.TrimSpace(line) == "[Install]" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func lintOverwrittenFiles(sources []sourceValue) []lintIssue {
+	type written struct {
+		in       input
+		contents string
+	}
+	var issues []lintIssue
+	files := map[string]written{}
+	for _, source := range sources {
+		for i, file := range jsonList(source.value, "storage", "files") {
+			filePath := jsonString(file, "path")
+			// inputs that don't set contents (e.g. only a mode or append)
+			// don't overwrite them
+			if obj, _ := file["contents"].(map[string]interface{}); jsonString(obj, "source") == "" {
+				continue
+			}
+			contents, _ := json.Marshal(file["contents"])
+			if prev, ok := files[filePath]; ok && prev.contents != string(contents) {
+				issues = append(issues, lintIssue{
+					in:      source.in,
+					path:    fmt.Sprintf("$.storage.files.%d", i),
+					message: fmt.Sprintf("file %s from %s is overwritten with different contents", filePath, prev.in.name),
+				})
+			}
+			files[filePath] = written{in: source.in, contents: string(contents)}
+		}
+	}
+	return issues
+}
+
+func lintUsers(config interface{}, sources []sourceValue) []lintIssue {
+	var issues []lintIssue
+	for _, user := range jsonList(config, "passwd", "users") {
+		name := jsonString(user, "name")
+		if system, _ := user["system"].(bool); system {
+			continue
+		}
+		if shell := jsonString(user, "shell"); strings.HasSuffix(shell, "/nologin") || strings.HasSuffix(shell, "/false") {
+			continue
+		}
+		if keys, _ := user["sshAuthorizedKeys"].([]interface{}); len(keys) > 0 || jsonString(user, "passwordHash") != "" {
+			continue
+		}
+		in, at := definedBy(sources, name, "name", "passwd", "users")
+		issues = append(issues, lintIssue{in: in, path: at, message: fmt.Sprintf("user %s has no ssh_authorized_keys or password_hash", name)})
+	}
+	return issues
+}
+
+// definedBy returns the last input whose config has a list element with a
+// key value, and the element's path.
+func definedBy(sources []sourceValue, value, key string, fields ...string) (input, string) {
+	for i := len(sources) - 1; i >= 0; i-- {
+		for j, elem := range jsonList(sources[i].value, fields...) {
+			if jsonString(elem, key) == value {
+				return sources[i].in, fmt.Sprintf("$.%s.%d", strings.Join(fields, "."), j)
+			}
+		}
+	}
+	return contentInput, "$"
+}
+
+// jsonList returns the objects of a list in a generic JSON value.
+func jsonList(value interface{}, fields ...string) []map[string]interface{} {
+	for _, field := range fields {
+		obj, _ := value.(map[string]interface{})
+		value = obj[field]
+	}
+	list, _ := value.([]interface{})
+	objs := make([]map[string]interface{}, 0, len(list))
+	for _, elem := range list {
+		if obj, ok := elem.(map[string]interface{}); ok {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+func jsonString(obj map[string]interface{}, key string) string {
+	s, _ := obj[key].(string)
+	return s
+}
+
+// lintDiagnostics reports lint issues as warnings, or errors if strict
+// (except advisory issues).
+func lintDiagnostics(issues []lintIssue, strict bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, issue := range issues {
+		detail := fmt.Sprintf("%s: lint at %s", issue.in.name, issue.path)
+		if strict && !issue.advisory {
+			diags.AddAttributeError(issue.in.path, fmt.Sprintf("strict lint error: %s", issue.message), detail)
+		} else {
+			diags.AddAttributeWarning(issue.in.path, issue.message, detail)
+		}
+	}
+	return diags
+}
+
+// lintWarnings returns lint issues as warnings.
+func lintWarnings(issues []lintIssue) []warning {
+	var warnings []warning
+	for _, issue := range issues {
+		warnings = append(warnings, warning{message: issue.message, path: issue.path, source: issue.in.name})
+	}
+	return warnings
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// platformLimit is the user-data size limit of a platform.
+type platformLimit struct {
+	// maxSize is in bytes, with zero meaning no practical limit
+	maxSize int
+	// base64 is true if the limit applies to the base64 encoded user data
+	base64 bool
+}
+
+// platformLimits maps platforms to their user-data size limit.
+var platformLimits = map[string]platformLimit{
+	"aws":          {maxSize: 16 * 1024},
+	"azure":        {maxSize: 64 * 1024, base64: true},
+	"digitalocean": {maxSize: 64 * 1024},
+	"gcp":          {maxSize: 256 * 1024},
+	"openstack":    {maxSize: 64*1024 - 1, base64: true},
+	"qemu":         {},
+}
+
+// platforms lists platforms with user-data size presets.
+func platforms() []string {
+	names := make([]string, 0, len(platformLimits))
+	for name := range platformLimits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// maxSize returns the size limit in bytes for a config, from max_size or the
+// platform preset. Zero means no limit.
+func maxSize(cfg *configModel) int {
+	if !cfg.MaxSize.IsNull() {
+		return int(cfg.MaxSize.ValueInt64())
+	}
+	return platformLimits[cfg.Platform.ValueString()].maxSize
+}
+
+// limitsBase64 reports whether the size limit of a config applies to the
+// base64 encoded user data, as for platform limits that do.
+func limitsBase64(cfg *configModel) bool {
+	return cfg.MaxSize.IsNull() && platformLimits[cfg.Platform.ValueString()].base64
+}
+
+// configSize returns the size in bytes of a rendered config to compare with
+// the size limit, which may be the gzip compressed and base64 encoded size.
+func configSize(cfg *configModel, rendered string) (int, error) {
+	size := len(rendered)
+	if cfg.MaxSizeGzip.ValueBool() {
+		compressed, err := gzipCompress([]byte(rendered))
+		if err != nil {
+			return 0, err
+		}
+		size = len(compressed)
+	}
+	if limitsBase64(cfg) {
+		size = base64.StdEncoding.EncodedLen(size)
+	}
+	return size, nil
+}
+
+// sizeDiagnostic reports a rendered config that exceeds its size limit.
+func sizeDiagnostic(cfg *configModel, size, limit int) diag.Diagnostic {
+	output, attr := "rendered", path.Root("max_size")
+	if cfg.MaxSizeGzip.ValueBool() {
+		output = "gzip compressed rendered"
+	}
+	if limitsBase64(cfg) {
+		output = "base64 encoded " + output
+	}
+	limitFrom := "max_size"
+	if cfg.MaxSize.IsNull() {
+		limitFrom = fmt.Sprintf("%s user-data limit", cfg.Platform.ValueString())
+		attr = path.Root("platform")
+	}
+	return diag.NewAttributeErrorDiagnostic(
+		attr,
+		"rendered config exceeds size limit",
+		fmt.Sprintf("%s config is %d bytes, exceeding the %s of %d bytes", output, size, limitFrom, limit),
+	)
+}
@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// pointerConfig returns a compact Ignition config of the given spec version
+// that replaces itself with the full config fetched from a URL. The URL's
+// {sha512} placeholder is replaced by the full config's hex digest.
+func pointerConfig(url string, full []byte, spec ignitionSpec) ([]byte, error) {
+	hash := sha512Digest(full)
+	digest := strings.TrimPrefix(hash, "sha512-")
+
+	raw, err := json.Marshal(map[string]interface{}{
+		"ignition": map[string]interface{}{
+			"version": spec.Version(),
+			"config": map[string]interface{}{
+				"replace": map[string]interface{}{
+					"source": strings.ReplaceAll(url, "{sha512}", digest),
+					"verification": map[string]interface{}{
+						"hash": hash,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pointer, _, err := spec.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	return marshalJSON(pointer, false)
+}
@@ -0,0 +1,289 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// policyRule is a rule that values selected from an Ignition config must
+// satisfy.
+type policyRule struct {
+	name     string
+	selector []selectorStep
+	absent   bool
+	present  bool
+	equals   *string
+	oneOf    []string
+	matches  *regexp.Regexp
+	message  string
+	// severity of violations, error or warning
+	severity string
+}
+
+// violation is a selected value that breaks a policy rule.
+type violation struct {
+	rule     string
+	path     string
+	message  string
+	severity string
+}
+
+// selectorStep is one step of a selector (e.g. a field name, an index, all
+// elements, or elements whose field matches a glob).
+type selectorStep struct {
+	field  string
+	index  *int
+	all    bool
+	filter *selectorFilter
+}
+
+type selectorFilter struct {
+	field string
+	glob  string
+}
+
+// selected is a value (nil if the field is missing) at a path.
+type selected struct {
+	path  string
+	key   string
+	value interface{}
+}
+
+// parseSelector parses a selector of dot separated fields, each optionally
+// followed by [*] (all elements), [N] (element N), or [field=glob] (elements
+// whose field matches the glob), e.g. storage.files[path=/etc/ssh/**].mode.
+func parseSelector(selector string) ([]selectorStep, error) {
+	var steps []selectorStep
+	rest := selector
+	for rest != "" {
+		end := strings.IndexAny(rest, ".[")
+		if end == -1 {
+			end = len(rest)
+		}
+		if end == 0 {
+			return nil, fmt.Errorf("invalid selector %q: expected field name", selector)
+		}
+		steps = append(steps, selectorStep{field: rest[:end]})
+		rest = rest[end:]
+
+		for strings.HasPrefix(rest, "[") {
+			close := strings.Index(rest, "]")
+			if close == -1 {
+				return nil, fmt.Errorf("invalid selector %q: unclosed [", selector)
+			}
+			step, err := parseBracket(rest[1:close])
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector %q: %v", selector, err)
+			}
+			steps = append(steps, step)
+			rest = rest[close+1:]
+		}
+
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			if rest == "" {
+				return nil, fmt.Errorf("invalid selector %q: trailing .", selector)
+			}
+		} else if rest != "" {
+			return nil, fmt.Errorf("invalid selector %q: unexpected %q", selector, rest)
+		}
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("invalid selector %q: empty", selector)
+	}
+	return steps, nil
+}
+
+func parseBracket(expr string) (selectorStep, error) {
+	if expr == "*" {
+		return selectorStep{all: true}, nil
+	}
+	if field, glob, ok := strings.Cut(expr, "="); ok {
+		if field == "" {
+			return selectorStep{}, fmt.Errorf("filter [%s] requires a field", expr)
+		}
+		if _, err := path.Match(glob, ""); err != nil {
+			return selectorStep{}, fmt.Errorf("filter [%s]: %v", expr, err)
+		}
+		return selectorStep{filter: &selectorFilter{field: field, glob: glob}}, nil
+	}
+	i, err := strconv.Atoi(expr)
+	if err != nil || i < 0 {
+		return selectorStep{}, fmt.Errorf("expected [*], [N], or [field=glob], got [%s]", expr)
+	}
+	return selectorStep{index: &i}, nil
+}
+
+// selectValues returns the values selected from a generic JSON value. A
+// field missing from a selected object is selected as nil, so rules can
+// require it. A field of a list is selected from each of its elements.
+func selectValues(value interface{}, steps []selectorStep, at selected) []selected {
+	if len(steps) == 0 {
+		at.value = value
+		return []selected{at}
+	}
+	step, rest := steps[0], steps[1:]
+
+	if step.field != "" {
+		if _, ok := value.([]interface{}); ok {
+			// as if the field were preceded by [*]
+			return selectValues(value, append([]selectorStep{{all: true}}, steps...), at)
+		}
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		next := selected{path: at.path + "." + step.field, key: step.field}
+		child, ok := obj[step.field]
+		if !ok {
+			if len(rest) == 0 {
+				return []selected{next}
+			}
+			return nil
+		}
+		return selectValues(child, rest, next)
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	var values []selected
+	for i, elem := range list {
+		switch {
+		case step.index != nil && *step.index != i:
+			continue
+		case step.filter != nil:
+			obj, _ := elem.(map[string]interface{})
+			field, ok := obj[step.filter.field].(string)
+			if !ok {
+				continue
+			}
+			if !matchFilter(step.filter.glob, field) {
+				continue
+			}
+		}
+		values = append(values, selectValues(elem, rest, selected{path: fmt.Sprintf("%s.%d", at.path, i), key: at.key})...)
+	}
+	return values
+}
+
+// matchFilter reports whether a value matches the glob of a filter. The
+// glob is matched per slash separated segment, where a ** segment matches
+// any number of segments (e.g. /etc/ssh/** matches files at any depth).
+func matchFilter(glob, value string) bool {
+	return matchSegments(strings.Split(glob, "/"), strings.Split(value, "/"))
+}
+
+func matchSegments(globs, segments []string) bool {
+	if len(globs) == 0 {
+		return len(segments) == 0
+	}
+	if globs[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(globs[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(globs[0], segments[0]); !matched {
+		return false
+	}
+	return matchSegments(globs[1:], segments[1:])
+}
+
+// evaluate returns the violations of rules by an Ignition config, whose
+// fields are named as in Butane (e.g. password_hash).
+func evaluate(config interface{}, rules []policyRule) []violation {
+	var violations []violation
+	for _, rule := range rules {
+		values := selectValues(config, rule.selector, selected{path: "$"})
+		if rule.present && len(values) == 0 {
+			violations = append(violations, rule.violation("$", "no value is selected"))
+		}
+		for _, v := range values {
+			if problem := rule.check(v); problem != "" {
+				violations = append(violations, rule.violation(v.path, problem))
+			}
+		}
+	}
+	return violations
+}
+
+// check returns why a selected value breaks the rule, or "" if it doesn't.
+func (rule policyRule) check(v selected) string {
+	if v.value == nil {
+		if rule.present || rule.equals != nil || len(rule.oneOf) > 0 || rule.matches != nil {
+			return "value is missing"
+		}
+		return ""
+	}
+	formatted := formatValue(v.key, v.value)
+	switch {
+	case rule.absent:
+		// the value isn't shown, since it may be a secret (e.g. password_hash)
+		return "value is present"
+	case rule.equals != nil && !valueEquals(v.value, *rule.equals):
+		return fmt.Sprintf("value %s doesn't equal %s", formatted, *rule.equals)
+	case len(rule.oneOf) > 0 && !valueIn(v.value, rule.oneOf):
+		return fmt.Sprintf("value %s isn't one of the allowed values", formatted)
+	case rule.matches != nil && !rule.matches.MatchString(formatted):
+		return fmt.Sprintf("value %s doesn't match %s", formatted, rule.matches)
+	}
+	return ""
+}
+
+func (rule policyRule) violation(path, problem string) violation {
+	message := problem
+	if rule.message != "" {
+		message = fmt.Sprintf("%s (%s)", rule.message, problem)
+	}
+	return violation{rule: rule.name, path: path, message: message, severity: rule.severity}
+}
+
+// valueEquals compares a JSON value with an expected value. Numbers may be
+// written in octal (e.g. 0600), as file modes are in Butane.
+func valueEquals(value interface{}, expected string) bool {
+	switch value := value.(type) {
+	case float64:
+		n, err := strconv.ParseInt(expected, 0, 64)
+		return err == nil && float64(n) == value
+	case string:
+		return value == expected
+	default:
+		return formatValue("", value) == expected
+	}
+}
+
+func valueIn(value interface{}, allowed []string) bool {
+	for _, expected := range allowed {
+		if valueEquals(value, expected) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatValue formats a JSON value for messages, with modes in octal.
+func formatValue(key string, value interface{}) string {
+	switch value := value.(type) {
+	case float64:
+		if key == "mode" {
+			return fmt.Sprintf("0%o", int64(value))
+		}
+		return strconv.FormatInt(int64(value), 10)
+	case string:
+		return value
+	default:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+}
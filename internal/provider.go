@@ -1,14 +1,87 @@
 package internal
 
 import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 // Provider returns a config transpiler Provider.
 func Provider() *schema.Provider {
 	return &schema.Provider{
+		Schema: map[string]*schema.Schema{
+			"files_dir": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "default files_dir for data sources that don't set one",
+			},
+			"pretty_print": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "default pretty_print for data sources that don't set one",
+			},
+			"snippets": {
+				Type: schema.TypeList,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Optional:    true,
+				Description: "default snippets for data sources that don't set any",
+			},
+			"strict": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "default strict for data sources that don't set one",
+			},
+			"sensitive": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "default sensitive for data sources that don't set one",
+			},
+		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"ct_config": DatasourceConfig(),
+			"ct_ignition_merge": DatasourceIgnitionMerge(),
 		},
 	}
 }
+
+// providerMeta holds provider-level defaults used by data sources.
+type providerMeta struct {
+	filesDir  string
+	pretty    bool
+	snippets  []string
+	strict    bool
+	sensitive bool
+}
+
+// stringList converts a Terraform list of strings, treating null elements
+// as empty strings.
+func stringList(list []interface{}) []string {
+	strs := make([]string, len(list))
+	for i, v := range list {
+		if v != nil {
+			strs[i] = v.(string)
+		}
+	}
+	return strs
+}
+
+// listStrings converts a framework list of strings, treating null elements
+// as empty strings.
+func listStrings(list types.List) []string {
+	elems := list.Elements()
+	strs := make([]string, len(elems))
+	for i, v := range elems {
+		strs[i] = v.(types.String).ValueString()
+	}
+	return strs
+}
+
+// mapStrings converts a framework map of strings, treating null elements as
+// empty strings.
+func mapStrings(m types.Map) map[string]string {
+	strs := map[string]string{}
+	for key, v := range m.Elements() {
+		strs[key] = v.(types.String).ValueString()
+	}
+	return strs
+}
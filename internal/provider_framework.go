@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// frameworkProvider serves the parts of the config transpiler provider
+// migrated to terraform-plugin-framework (e.g. ct_config, provider functions).
+// It's muxed with the SDKv2 Provider, so its provider schema must match
+// exactly.
+type frameworkProvider struct{}
+
+var (
+	_ provider.ProviderWithFunctions          = &frameworkProvider{}
+	_ provider.ProviderWithEphemeralResources = &frameworkProvider{}
+)
+
+// NewFrameworkProvider returns the framework config transpiler provider.
+func NewFrameworkProvider() provider.Provider {
+	return &frameworkProvider{}
+}
+
+func (p *frameworkProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "ct"
+}
+
+func (p *frameworkProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"files_dir": schema.StringAttribute{
+				Optional:    true,
+				Description: "default files_dir for data sources that don't set one",
+			},
+			"pretty_print": schema.BoolAttribute{
+				Optional:    true,
+				Description: "default pretty_print for data sources that don't set one",
+			},
+			"snippets": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "default snippets for data sources that don't set any",
+			},
+			"strict": schema.BoolAttribute{
+				Optional:    true,
+				Description: "default strict for data sources that don't set one",
+			},
+			"sensitive": schema.BoolAttribute{
+				Optional:    true,
+				Description: "default sensitive for data sources that don't set one",
+			},
+		},
+	}
+}
+
+// providerModel is the provider configuration.
+type providerModel struct {
+	FilesDir    types.String `tfsdk:"files_dir"`
+	PrettyPrint types.Bool   `tfsdk:"pretty_print"`
+	Snippets    types.List   `tfsdk:"snippets"`
+	Strict      types.Bool   `tfsdk:"strict"`
+	Sensitive   types.Bool   `tfsdk:"sensitive"`
+}
+
+func (p *frameworkProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	var cfg providerModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &cfg)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	meta := &providerMeta{
+		filesDir:  cfg.FilesDir.ValueString(),
+		pretty:    cfg.PrettyPrint.ValueBool(),
+		snippets:  listStrings(cfg.Snippets),
+		strict:    cfg.Strict.ValueBool(),
+		sensitive: cfg.Sensitive.ValueBool(),
+	}
+	resp.DataSourceData = meta
+	resp.EphemeralResourceData = meta
+}
+
+func (p *frameworkProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
+	return []func() datasource.DataSource{
+		NewConfigDataSource,
+		NewIgnitionToButaneDataSource,
+		NewIgnitionValidateDataSource,
+		NewPolicyCheckDataSource,
+	}
+}
+
+func (p *frameworkProvider) Resources(ctx context.Context) []func() resource.Resource {
+	return nil
+}
+
+func (p *frameworkProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		NewConfigEphemeralResource,
+	}
+}
+
+func (p *frameworkProvider) Functions(ctx context.Context) []func() function.Function {
+	return []func() function.Function{
+		NewButaneToIgnitionFunction,
+		NewMergeIgnitionFunction,
+		NewIgnitionToButaneFunction,
+	}
+}
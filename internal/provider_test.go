@@ -1,17 +1,155 @@
 package internal
 
 import (
+	"context"
+	"regexp"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	r "github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 )
 
-var testProviders = map[string]*schema.Provider{
-	"ct": Provider(),
+// testProtoV5ProviderFactories serve the muxed SDKv2 and framework providers
+var testProtoV5ProviderFactories = map[string]func() (tfprotov5.ProviderServer, error){
+	"ct": func() (tfprotov5.ProviderServer, error) {
+		server, err := ProviderServer(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		return server(), nil
+	},
 }
 
+// testFunctionProviders declares the provider (as registered by the test
+// harness), which is required to call its functions
+const testFunctionProviders = `
+terraform {
+  required_providers {
+    ct = {
+      source = "hashicorp/ct"
+    }
+  }
+}
+`
+
 func TestProvider(t *testing.T) {
 	if err := Provider().InternalValidate(); err != nil {
 		t.Fatalf("err: %s", err)
 	}
 }
+
+func TestProviderServer(t *testing.T) {
+	server, err := testProtoV5ProviderFactories["ct"]()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	resp, err := server.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, d := range resp.Diagnostics {
+		t.Errorf("%s: %s", d.Summary, d.Detail)
+	}
+}
+
+const providerDefaults = `
+provider "ct" {
+  pretty_print = true
+  strict = true
+  snippets = [
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      enabled: true
+EOT
+  ]
+}
+
+data "ct_config" "defaults" {
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - key
+EOT
+}
+`
+
+const providerDefaultsOverride = `
+provider "ct" {
+  pretty_print = true
+  strict = true
+}
+
+data "ct_config" "defaults" {
+  pretty_print = false
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - key
+EOT
+  snippets = [
+<<EOT
+---
+variant: fcos
+version: 1.5.0
+systemd:
+  units:
+    - name: docker.service
+      enabled: true
+EOT
+  ]
+}
+`
+
+const providerDefaultsStrict = `
+provider "ct" {
+  strict = true
+}
+
+data "ct_config" "defaults" {
+  content = <<EOT
+---
+variant: fcos
+version: 1.5.0
+unknown_key: true
+EOT
+}
+`
+
+func TestProviderDefaults(t *testing.T) {
+	r.UnitTest(t, r.TestCase{
+		ProtoV5ProviderFactories: testProtoV5ProviderFactories,
+		Steps: []r.TestStep{
+			{
+				Config: providerDefaults,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.defaults", "rendered", ignitionV34WithSnippetsExpected),
+				),
+			},
+			{
+				Config: providerDefaultsOverride,
+				Check: r.ComposeTestCheckFunc(
+					r.TestCheckResourceAttr("data.ct_config.defaults", "rendered", ignitionV34WithSnippetsPrettyFalseExpected),
+				),
+			},
+			{
+				Config:      providerDefaultsStrict,
+				ExpectError: regexp.MustCompile("strict parsing error"),
+			},
+		},
+	})
+}
@@ -0,0 +1,24 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-mux/tf5muxserver"
+)
+
+// ProviderServer returns a protocol v5 server muxing the SDKv2 Provider and
+// the framework provider.
+func ProviderServer(ctx context.Context) (func() tfprotov5.ProviderServer, error) {
+	servers := []func() tfprotov5.ProviderServer{
+		Provider().GRPCProvider,
+		providerserver.NewProtocol5(NewFrameworkProvider()),
+	}
+
+	mux, err := tf5muxserver.NewMuxServer(ctx, servers...)
+	if err != nil {
+		return nil, err
+	}
+	return mux.ProviderServer, nil
+}
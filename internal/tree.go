@@ -0,0 +1,252 @@
+package internal
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultMaxTreeFileSize is the default size limit of files in trees, in
+// bytes.
+const defaultMaxTreeFileSize = 1024 * 1024
+
+type treeModel struct {
+	Local       types.String `tfsdk:"local"`
+	Path        types.String `tfsdk:"path"`
+	Include     types.List   `tfsdk:"include"`
+	Exclude     types.List   `tfsdk:"exclude"`
+	Modes       types.Map    `tfsdk:"modes"`
+	MaxFileSize types.Int64  `tfsdk:"max_file_size"`
+}
+
+// tree mirrors the files of a local directory into a config.
+type tree struct {
+	in      input
+	local   string
+	path    string
+	include []string
+	exclude []string
+	// modes maps globs to the mode of files they match
+	modes       map[string]int
+	maxFileSize int64
+}
+
+// treeFile is a file of a tree, by its slash separated path relative to the
+// tree's local directory.
+type treeFile struct {
+	rel  string
+	mode int
+}
+
+// parseTrees converts and validates tree blocks.
+func parseTrees(models []treeModel) ([]tree, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	var trees []tree
+	for i, m := range models {
+		t := tree{
+			in:          listInput("trees", i),
+			local:       m.Local.ValueString(),
+			path:        m.Path.ValueString(),
+			include:     listStrings(m.Include),
+			exclude:     listStrings(m.Exclude),
+			modes:       map[string]int{},
+			maxFileSize: defaultMaxTreeFileSize,
+		}
+		if !m.MaxFileSize.IsNull() {
+			t.maxFileSize = m.MaxFileSize.ValueInt64()
+		}
+		for _, glob := range append(append([]string{}, t.include...), t.exclude...) {
+			if _, err := path.Match(glob, ""); err != nil {
+				diags.AddAttributeError(t.in.path, "invalid tree glob", fmt.Sprintf("%s: %q: %v", t.in.name, glob, err))
+			}
+		}
+		for glob, mode := range mapStrings(m.Modes) {
+			if _, err := path.Match(glob, ""); err != nil {
+				diags.AddAttributeError(t.in.path.AtName("modes"), "invalid tree glob", fmt.Sprintf("%s: %q: %v", t.in.name, glob, err))
+			}
+			parsed, err := strconv.ParseUint(mode, 8, 32)
+			if err != nil || parsed > 07777 {
+				diags.AddAttributeError(t.in.path.AtName("modes"), "invalid tree mode", fmt.Sprintf("%s: %q must be an octal mode (e.g. 0644)", t.in.name, mode))
+			}
+			t.modes[glob] = int(parsed)
+		}
+		trees = append(trees, t)
+	}
+	return trees, diags
+}
+
+// matchGlob reports whether a glob matches a slash separated relative path,
+// where a ** segment matches any number of directories as in policy filters.
+// Globs without a / match the base name at any depth (e.g. *.conf).
+func matchGlob(glob, rel string) bool {
+	if !strings.Contains(glob, "/") {
+		rel = path.Base(rel)
+	}
+	return matchFilter(glob, rel)
+}
+
+func matchAny(globs []string, rel string) bool {
+	for _, glob := range globs {
+		if matchGlob(glob, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// files returns the regular files of a tree that are included and not
+// excluded. Excluded directories are skipped. Symlinks, other special files,
+// and files larger than the tree's limit are errors.
+func (t tree) files() ([]treeFile, error) {
+	info, err := os.Stat(t.local)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", t.local)
+	}
+
+	var files []treeFile
+	err = filepath.WalkDir(t.local, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(t.local, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		if matchAny(t.exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			return fmt.Errorf("%s is a symlink, which trees don't support (exclude it or copy its target)", p)
+		case d.IsDir():
+			return nil
+		case !d.Type().IsRegular():
+			return fmt.Errorf("%s is not a regular file", p)
+		}
+		if len(t.include) > 0 && !matchAny(t.include, rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > t.maxFileSize {
+			return fmt.Errorf("%s is %d bytes, larger than the max_file_size of %d bytes", p, info.Size(), t.maxFileSize)
+		}
+		mode, err := t.mode(rel, info.Mode())
+		if err != nil {
+			return err
+		}
+		files = append(files, treeFile{rel: rel, mode: mode})
+		return nil
+	})
+	return files, err
+}
+
+// mode returns the mode of a file from the glob in modes matching it, or
+// else 0755 for executable files and 0644 for others, as Butane trees do.
+func (t tree) mode(rel string, perm fs.FileMode) (int, error) {
+	var globs []string
+	for glob := range t.modes {
+		if matchGlob(glob, rel) {
+			globs = append(globs, glob)
+		}
+	}
+	sort.Strings(globs)
+	switch {
+	case len(globs) > 1:
+		return 0, fmt.Errorf("%s matches more than one modes glob (%s)", rel, strings.Join(globs, ", "))
+	case len(globs) == 1:
+		return t.modes[globs[0]], nil
+	case perm&0111 != 0:
+		return 0755, nil
+	default:
+		return 0644, nil
+	}
+}
+
+// butaneTree is a Butane snippet with the files of a tree.
+type butaneTree struct {
+	Variant string `yaml:"variant"`
+	Version string `yaml:"version"`
+	Storage struct {
+		Files []butaneTreeFile `yaml:"files"`
+	} `yaml:"storage"`
+}
+
+type butaneTreeFile struct {
+	Path     string `yaml:"path"`
+	Mode     int    `yaml:"mode"`
+	Contents struct {
+		Local string `yaml:"local"`
+	} `yaml:"contents"`
+}
+
+// snippet returns a Butane snippet of the given variant and version with the
+// tree's files, whose local contents are relative to the tree.
+func (t tree) snippet(variant, version string) (snippet, error) {
+	files, err := t.files()
+	if err != nil {
+		return snippet{}, err
+	}
+	config := butaneTree{Variant: variant, Version: version}
+	for _, f := range files {
+		file := butaneTreeFile{Path: path.Join(t.path, f.rel), Mode: f.mode}
+		file.Contents.Local = f.rel
+		config.Storage.Files = append(config.Storage.Files, file)
+	}
+	content, err := yaml.Marshal(config)
+	if err != nil {
+		return snippet{}, err
+	}
+	return snippet{content: string(content), in: t.in, filesDir: t.local}, nil
+}
+
+// treeSnippets returns Butane snippets with the files of trees, using the
+// variant and version of the content.
+func treeSnippets(trees []tree, content string) ([]snippet, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if len(trees) == 0 {
+		return nil, diags
+	}
+	var header struct {
+		Variant string `yaml:"variant"`
+		Version string `yaml:"version"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &header); err != nil || header.Variant == "" || header.Version == "" {
+		diags.AddAttributeError(contentInput.path, "trees require a Butane config", "content must set variant and version to use trees")
+		return nil, diags
+	}
+
+	var snippets []snippet
+	for _, t := range trees {
+		s, err := t.snippet(header.Variant, header.Version)
+		if err != nil {
+			diags.Append(t.in.errorDiagnostic("tree error", err))
+			continue
+		}
+		snippets = append(snippets, s)
+	}
+	return snippets, diags
+}
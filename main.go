@@ -1,13 +1,28 @@
 package main
 
 import (
-	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
+	"context"
+	"log"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tf5server"
 
 	"github.com/poseidon/terraform-provider-ct/internal"
 )
 
 func main() {
-	plugin.Serve(&plugin.ServeOpts{
-		ProviderFunc: internal.Provider,
-	})
+	// Terraform runs the provider without arguments
+	if len(os.Args) > 1 {
+		os.Exit(internal.RunCLI(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
+	server, err := internal.ProviderServer(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = tf5server.Serve("registry.terraform.io/poseidon/ct", server)
+	if err != nil {
+		log.Fatal(err)
+	}
 }